@@ -0,0 +1,223 @@
+package redislock
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Backend is the interface used by redislock to talk to redis, it does not depend on a redis client library.
+// NewClient wraps a go-redis RedisClient into a Backend,
+// NewClientWithBackend takes other clients, such as the adapter in redislockrueidis.
+type Backend interface {
+	// Eval runs script with keys and args by EVALSHA, falling back to EVAL if the script is not loaded.
+	// args are strings, []byte or integers. The reply is converted like go-redis does:
+	// integers to int64, bulk and status strings to string and arrays to []interface{},
+	// a nil reply returns a nil value and a nil error.
+	Eval(ctx context.Context, script *Script, keys []string, args ...interface{}) (interface{}, error)
+	// SetNX sets key to value with expiration if key does not exist, it reports whether key is set.
+	SetNX(ctx context.Context, key, value string, expiration time.Duration) (bool, error)
+	// Get returns the string value of key, found is false if key does not exist.
+	Get(ctx context.Context, key string) (value string, found bool, err error)
+	// Subscribe subscribes channel and returns once the subscription is confirmed,
+	// the returned channel receives a value after messages are published, extra values may be dropped.
+	// It returns a nil channel if the backend does not support pub/sub, so redislock polls instead.
+	// close unsubscribes channel.
+	Subscribe(ctx context.Context, channel string) (notify <-chan struct{}, close func(), err error)
+}
+
+// ScriptLoader is implemented by a Backend which manages the script cache of redis,
+// it lets Client.Warmup preload the scripts and the client reload all of them once one is missing.
+type ScriptLoader interface {
+	// EvalSha runs script by EVALSHA only, it returns the NOSCRIPT error of redis if the script is not loaded.
+	EvalSha(ctx context.Context, script *Script, keys []string, args ...interface{}) (interface{}, error)
+	// ScriptLoad loads script into the script cache.
+	ScriptLoad(ctx context.Context, script *Script) error
+	// ScriptExists reports whether each of scripts is in the script cache.
+	ScriptExists(ctx context.Context, scripts ...*Script) ([]bool, error)
+}
+
+// FunctionCaller is implemented by a Backend which supports the functions of redis 7, see WithFunctions.
+type FunctionCaller interface {
+	// FCall calls function with keys and args, the reply is converted like Eval.
+	// It returns ErrFunctionsUnsupported if the redis client cannot call functions.
+	FCall(ctx context.Context, function string, keys []string, args ...interface{}) (interface{}, error)
+	// FunctionLoad loads library by FUNCTION LOAD REPLACE.
+	FunctionLoad(ctx context.Context, library string) error
+}
+
+// ReplicaWaiter is implemented by a Backend which can wait for the replicas to acknowledge a script, see WithWait.
+type ReplicaWaiter interface {
+	// EvalWait runs script like Eval, then WAIT numReplicas timeout on the same connection,
+	// and returns the reply of script and the count of replicas which acknowledged it.
+	// If script succeeds but WAIT fails, it returns the reply of script and the error of WAIT.
+	// It returns ErrWaitUnsupported before running script if the redis client cannot wait.
+	EvalWait(ctx context.Context, script *Script, keys []string, args []interface{}, numReplicas int, timeout time.Duration) (interface{}, int64, error)
+}
+
+// goRedisBackend is the Backend of a go-redis RedisClient.
+type goRedisBackend struct {
+	client RedisClient
+}
+
+func (b goRedisBackend) Eval(ctx context.Context, script *Script, keys []string, args ...interface{}) (interface{}, error) {
+	reply, err := b.client.EvalSha(ctx, script.Hash(), keys, args...).Result()
+	if err != nil && redis.HasErrorPrefix(err, "NOSCRIPT") {
+		reply, err = b.client.Eval(withNoScriptRetry(ctx), script.Source(), keys, args...).Result()
+	}
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return reply, err
+}
+
+func (b goRedisBackend) EvalSha(ctx context.Context, script *Script, keys []string, args ...interface{}) (interface{}, error) {
+	reply, err := b.client.EvalSha(ctx, script.Hash(), keys, args...).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return reply, err
+}
+
+func (b goRedisBackend) ScriptLoad(ctx context.Context, script *Script) error {
+	return b.client.ScriptLoad(ctx, script.Source()).Err()
+}
+
+func (b goRedisBackend) ScriptExists(ctx context.Context, scripts ...*Script) ([]bool, error) {
+	hashes := make([]string, len(scripts))
+	for i, script := range scripts {
+		hashes[i] = script.Hash()
+	}
+	return b.client.ScriptExists(ctx, hashes...).Result()
+}
+
+// functioner is implemented by go-redis clients which can call functions, such as *redis.Client.
+type functioner interface {
+	FCall(ctx context.Context, function string, keys []string, args ...interface{}) *redis.Cmd
+	FunctionLoadReplace(ctx context.Context, code string) *redis.StringCmd
+}
+
+func (b goRedisBackend) FCall(ctx context.Context, function string, keys []string, args ...interface{}) (interface{}, error) {
+	f, ok := b.client.(functioner)
+	if !ok {
+		return nil, ErrFunctionsUnsupported
+	}
+
+	reply, err := f.FCall(ctx, function, keys, args...).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return reply, err
+}
+
+func (b goRedisBackend) FunctionLoad(ctx context.Context, library string) error {
+	f, ok := b.client.(functioner)
+	if !ok {
+		return ErrFunctionsUnsupported
+	}
+	return f.FunctionLoadReplace(ctx, library).Err()
+}
+
+// conner is implemented by go-redis clients of a single redis, such as *redis.Client,
+// whose connections WAIT needs, the clients of a cluster are not.
+type conner interface {
+	Conn() *redis.Conn
+}
+
+func (b goRedisBackend) EvalWait(ctx context.Context, script *Script, keys []string, args []interface{}, numReplicas int, timeout time.Duration) (interface{}, int64, error) {
+	c, ok := b.client.(conner)
+	if !ok {
+		return nil, 0, ErrWaitUnsupported
+	}
+
+	conn := c.Conn()
+	defer conn.Close()
+
+	reply, err := goRedisBackend{client: conn}.Eval(ctx, script, keys, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	acked, err := conn.Wait(ctx, numReplicas, timeout).Result()
+	return reply, acked, err
+}
+
+func (b goRedisBackend) SetNX(ctx context.Context, key, value string, expiration time.Duration) (bool, error) {
+	return b.client.SetNX(ctx, key, value, expiration).Result()
+}
+
+func (b goRedisBackend) Get(ctx context.Context, key string) (string, bool, error) {
+	value, err := b.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+func (b goRedisBackend) Subscribe(ctx context.Context, channel string) (<-chan struct{}, func(), error) {
+	s, ok := b.client.(subscriber)
+	if !ok {
+		return nil, func() {}, nil
+	}
+	return subscribe(ctx, s, channel)
+}
+
+// eval runs script on the backend of the client,
+// the reply is wrapped in a *redis.Cmd, whose methods convert it, a nil reply is redis.Nil.
+func (c *Client) eval(ctx context.Context, script *Script, keys []string, args ...interface{}) *redis.Cmd {
+	reply, err := c.run(ctx, script, keys, args...)
+	if err == nil && reply == nil {
+		err = redis.Nil
+	}
+	return redis.NewCmdResult(reply, err)
+}
+
+// run runs script as a function if the client uses functions, otherwise by EVALSHA.
+// If the backend is a ScriptLoader and script is not loaded, script is run by EVAL at once
+// and all scripts are reloaded in the background.
+func (c *Client) run(ctx context.Context, script *Script, keys []string, args ...interface{}) (interface{}, error) {
+	if c.functions && script.name != "" && atomic.LoadInt32(&c.noFunctions) == 0 && atomic.LoadInt32(&c.newerLibrary) == 0 {
+		if caller, ok := c.backend.(FunctionCaller); ok {
+			if reply, ok, err := c.fcall(ctx, caller, script, keys, args...); ok {
+				return reply, err
+			}
+		}
+	}
+
+	loader, ok := c.backend.(ScriptLoader)
+	if !ok {
+		return c.backend.Eval(ctx, script, keys, args...)
+	}
+
+	reply, err := loader.EvalSha(ctx, script, keys, args...)
+	if isNoScript(err) {
+		c.reloadScripts(loader)
+		reply, err = c.backend.Eval(withNoScriptRetry(ctx), script, keys, args...)
+	}
+	return reply, err
+}
+
+// isNoScript reports whether err is the NOSCRIPT error of redis.
+func isNoScript(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT")
+}
+
+// noScriptRetryKey is the context key marking the retry of a script answered with NOSCRIPT.
+type noScriptRetryKey struct{}
+
+func withNoScriptRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noScriptRetryKey{}, true)
+}
+
+// IsNoScriptRetry reports whether ctx is of an EVALSHA or EVAL that retries a script answered with NOSCRIPT,
+// so wrappers of a RedisClient, such as redislocktest.FaultyClient, can tell the retry from a new call.
+func IsNoScriptRetry(ctx context.Context) bool {
+	retry, _ := ctx.Value(noScriptRetryKey{}).(bool)
+	return retry
+}
@@ -0,0 +1,93 @@
+package redislock
+
+import (
+	"context"
+	"time"
+)
+
+// Barrier is a distributed cyclic barrier based on redis,
+// the parties calling Await block until all of them arrive, then the barrier is reset.
+type Barrier struct {
+	client       *Client
+	key          string
+	parties      int64
+	expiration   time.Duration // refreshed by every arrival and waiting party, so abandoned barriers expire.
+	pollInterval time.Duration // interval waiters check and refresh the barrier.
+}
+
+// NewBarrier creates a new Barrier of key for parties, every party must use the same parties.
+func (c *Client) NewBarrier(key string, parties int64, expiration time.Duration) *Barrier {
+	pollInterval := DefaultPollInterval
+	// waiters refresh the barrier when they check it, which must happen before it expires.
+	if pollInterval > expiration/3 {
+		pollInterval = expiration / 3
+	}
+
+	return &Barrier{
+		client:       c,
+		key:          key,
+		parties:      parties,
+		expiration:   expiration,
+		pollInterval: pollInterval,
+	}
+}
+
+// Await arrives at the barrier and blocks until all parties arrive or ctx is done,
+// if ctx is done, the party leaves the barrier, so it is not counted.
+// The waiting parties keep the barrier from expiring, if it expires anyway,
+// for example because redis loses it, Await returns ErrBarrierBroken.
+func (b *Barrier) Await(ctx context.Context) error {
+	notify, closeSubscription, err := b.client.backend.Subscribe(ctx, b.channel())
+	if err != nil {
+		return err
+	}
+	defer closeSubscription()
+
+	generation, err := b.client.eval(ctx, luaBarrierArrive, []string{b.key}, b.parties, b.expiration.Milliseconds(), b.channel()).Int64()
+	if err != nil {
+		return err
+	}
+
+	// -1 means all parties arrive.
+	if generation == -1 {
+		return nil
+	}
+
+	for {
+		if err = b.client.waitNotified(ctx, notify, b.pollInterval); err != nil {
+			b.leave(generation)
+			return err
+		}
+
+		current, err := b.client.eval(ctx, luaBarrierWait, []string{b.key}, b.expiration.Milliseconds()).Int64()
+		if err != nil {
+			b.leave(generation)
+			return err
+		}
+
+		// -1 means the barrier has expired, it can not tell whether all parties arrived.
+		if current == -1 {
+			return ErrBarrierBroken
+		}
+
+		if current != generation {
+			return nil
+		}
+	}
+}
+
+// leave removes the party waiting for generation from the barrier.
+func (b *Barrier) leave(generation int64) {
+	// leave even if the caller has given up, bounded so a hung connection does not block.
+	ctx, cancel := context.WithTimeout(context.Background(), ReleaseTimeout)
+	defer cancel()
+
+	if err := b.client.eval(ctx, luaBarrierLeave, []string{b.key}, generation).Err(); err != nil {
+		b.client.logger.Error("redislock: leave barrier failed", "key", b.key, "error", err)
+	}
+}
+
+// channel returns the channel notified when all parties arrive.
+func (b *Barrier) channel() string {
+	return b.key + ":tripped"
+}
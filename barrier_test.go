@@ -0,0 +1,60 @@
+package redislock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestBarrier_Await(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	// init redislock client
+	client, err := NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+	key := "testBarrier"
+	defer teardown(t, rdb, []string{key})
+
+	ctx := context.Background()
+
+	t.Run("AwaitTimeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+
+		if err := client.NewBarrier(key, 3, 10*time.Second).Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Await error is not equal,expected %v, got %v", context.DeadlineExceeded, err)
+		}
+	})
+
+	// the barrier is reused by every generation.
+	for _, name := range []string{"FirstGeneration", "SecondGeneration"} {
+		t.Run(name, func(t *testing.T) {
+			awaited := make(chan error, 3)
+			for i := 0; i < 3; i++ {
+				go func() {
+					awaited <- client.NewBarrier(key, 3, 10*time.Second).Await(ctx)
+				}()
+			}
+
+			for i := 0; i < 3; i++ {
+				select {
+				case err := <-awaited:
+					if err != nil {
+						t.Errorf("Await error:[%v]", err)
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("Await is not released after all parties arrive")
+				}
+			}
+		})
+	}
+}
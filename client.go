@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rc4"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -13,18 +14,41 @@ import (
 type RedisClient interface {
 	redis.Scripter
 	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
+	Get(ctx context.Context, key string) *redis.StringCmd
 }
 
-// Client is the redislock client, wraps RedisClient.
+// Client is the redislock client, wraps a Backend.
 type Client struct {
-	redisClient RedisClient
+	backend     Backend
 	cipherKey   string // if cipherKey is "-1", use unix timestamp as Cipher cipherKey.
 	*rc4.Cipher        // customize cipher, default is rc4.NewCipher with cipherKey.
+	tracer      Tracer // traces lock operations, default is a no-op tracer.
+	logger      Logger // logs lock operations, default is a no-op logger.
+	fencing     bool   // if fencing is true, every acquired lock gets a fencing token.
+	clock       Clock  // measures deadlines, retries and the watch dog, default is the system clock.
+	reloading   int32  // 1 while all scripts are being reloaded after a NOSCRIPT error.
+
+	functions      bool  // call the scripts as functions of the library, see WithFunctions.
+	noFunctions    int32 // 1 once redis turns out not to support functions.
+	newerLibrary   int32 // 1 once a newer version of the function library turns out to be loaded.
+	loadingLibrary int32 // 1 while the function library is being loaded.
+
+	waitReplicas int           // replicas to acknowledge an acquire, 0 means no WAIT, see WithWait.
+	waitTimeout  time.Duration // timeout of WAIT.
+	waitRefresh  bool          // refreshes wait for the replicas too.
+
+	serverTime bool // deadlines are taken from the PTTL and TIME of redis, see WithServerTime.
 }
 
 // NewClient creates a new redislock client.
 func NewClient(redisClient RedisClient, options ...ClientOption) (*Client, error) {
-	c := &Client{redisClient: redisClient, cipherKey: "-1"}
+	return NewClientWithBackend(goRedisBackend{client: redisClient}, options...)
+}
+
+// NewClientWithBackend creates a new redislock client using backend,
+// it lets redis clients other than go-redis be used.
+func NewClientWithBackend(backend Backend, options ...ClientOption) (*Client, error) {
+	c := &Client{backend: backend, cipherKey: "-1", tracer: noopTracer{}, logger: noopLogger{}, clock: systemClock{}}
 
 	for _, option := range options {
 		option(c)
@@ -69,6 +93,79 @@ func WithCipher(cipher *rc4.Cipher) ClientOption {
 	}
 }
 
+// WithTracer sets the tracer of the client.
+func WithTracer(tracer Tracer) ClientOption {
+	return func(client *Client) {
+		client.tracer = tracer
+	}
+}
+
+// WithLogger sets the logger of the client.
+func WithLogger(logger Logger) ClientOption {
+	return func(client *Client) {
+		client.logger = logger
+	}
+}
+
+// WithFencing makes every acquired lock get a fencing token,
+// which increases each time the lock of the key is acquired.
+// The counter is stored in redis next to the lock, see FencingKey.
+func WithFencing() ClientOption {
+	return func(client *Client) {
+		client.fencing = true
+	}
+}
+
+// WithClock sets the clock of the client, default is the system clock.
+func WithClock(clock Clock) ClientOption {
+	return func(client *Client) {
+		client.clock = clock
+	}
+}
+
+// WithFunctions makes the client call the scripts as functions of a library loaded by FUNCTION LOAD,
+// functions persist across restarts and replicate like data since redis 7.
+// The library is loaded once a function is not found, and upgraded when LibraryVersion increases.
+// If the backend is not a FunctionCaller or redis does not support functions, the client uses scripts.
+func WithFunctions() ClientOption {
+	return func(client *Client) {
+		client.functions = true
+	}
+}
+
+// WithWait makes the client wait for numReplicas replicas to acknowledge every acquired lock by WAIT,
+// so the lock survives a failover of the primary. If fewer replicas acknowledge it within timeout,
+// the lock is released and the acquire fails with ErrNotEnoughReplicas, a timeout of 0 waits forever.
+// The backend must be a ReplicaWaiter, otherwise acquires fail with ErrWaitUnsupported.
+// Read and write locks and RWMutex.Upgrade wait too, RWMutex.Downgrade does not.
+// Waited writes are run by EVALSHA, also with WithFunctions.
+func WithWait(numReplicas int, timeout time.Duration) ClientOption {
+	return func(client *Client) {
+		client.waitReplicas = numReplicas
+		client.waitTimeout = timeout
+	}
+}
+
+// WithWaitOnRefresh makes refreshes, extensions and the watch dog wait for the replicas like acquires, see WithWait.
+// A refresh acknowledged by too few replicas returns ErrNotEnoughReplicas, the lock is still held on the primary.
+func WithWaitOnRefresh() ClientOption {
+	return func(client *Client) {
+		client.waitRefresh = true
+	}
+}
+
+// WithServerTime makes the client take the deadlines of locks from redis instead of the local clock:
+// acquires and refreshes return the PTTL of the lock and the TIME of redis, see Mutex.ServerDeadline,
+// and the watch dog schedules refreshes by the observed PTTL. A local clock skewed from redis
+// or running at another rate does not shift the validity of a lock then.
+// It applies to Mutex only, RWMutex keeps no deadlines and has no watch dog,
+// its read locks already expire by the TIME of redis.
+func WithServerTime() ClientOption {
+	return func(client *Client) {
+		client.serverTime = true
+	}
+}
+
 // TryLock tries to acquire a lock with default parameter.
 func (c *Client) TryLock(ctx context.Context, key string, expiration time.Duration) (*Mutex, error) {
 	option := &mutexOption{}
@@ -129,14 +226,42 @@ func (c *Client) TryLockWithRetryAndWatchDog(ctx context.Context, key string, re
 	return c.tryLock(ctx, key, watchDog.expiration, option)
 }
 
-func (c *Client) tryLock(ctx context.Context, key string, expiration time.Duration, option *mutexOption) (*Mutex, error) {
-	value, err := c.getValue()
+// TryLockWithTokenAndWatchDog tries to acquire a lock with watch dog, holding it as token instead of a generated value.
+// token must be unique to the holder, such as a value of NewToken with a prefix, or holders can release each other's locks.
+func (c *Client) TryLockWithTokenAndWatchDog(ctx context.Context, key, token string, watchDog *WatchDog) (*Mutex, error) {
+	option := &mutexOption{}
+	var err error
+
+	watchDog, err = checkWatchDogReturnWatchDog(watchDog)
 	if err != nil {
-		return nil, fmt.Errorf("c.getValue error: %w", err)
+		return nil, fmt.Errorf("checkWatchDogReturnWatchDog error: %w", err)
+	}
+
+	option.watchDog = watchDog
+	option.retryStrategy = NewNoRetry()
+	option.token = token
+
+	return c.tryLock(ctx, key, watchDog.expiration, option)
+}
+
+func (c *Client) tryLock(ctx context.Context, key string, expiration time.Duration, option *mutexOption) (_ *Mutex, err error) {
+	spanCtx, span := c.tracer.Start(ctx, OperationTryLock, key)
+	retryCount := 0
+	defer func() {
+		span.SetAttribute(AttributeRetryCount, retryCount)
+		span.SetAttribute(AttributeOutcome, tryLockOutcome(err))
+		span.End(err)
+	}()
+
+	value := option.token
+	if value == "" {
+		if value, err = c.getValue(); err != nil {
+			return nil, fmt.Errorf("c.getValue error: %w", err)
+		}
 	}
 
 	parentCtx := ctx
-	childCtx := ctx
+	childCtx := spanCtx
 
 	mutex := newMutex(c, key, value, expiration, option.retryStrategy)
 
@@ -144,48 +269,127 @@ func (c *Client) tryLock(ctx context.Context, key string, expiration time.Durati
 		mutex.setWatchDog(option.watchDog)
 	}
 
-	if _, ok := childCtx.Deadline(); !ok {
+	retryCount, err = c.retryLock(childCtx, key, value, expiration, option.retryStrategy, func(ctx context.Context) (bool, error) {
+		attemptAt := c.clock.Now()
+		ok, fencingToken, expiry, err := c.lock(ctx, key, value, expiration)
+		if err != nil {
+			c.logger.Error("redislock: acquire lock failed", "key", key, "token", logToken(value), "error", err)
+			return false, fmt.Errorf("c.lock error: %w", err)
+		}
+
+		if ok {
+			mutex.acquiredAt = attemptAt
+			mutex.deadline = attemptAt.Add(expiration)
+			mutex.fencingToken = fencingToken
+			if c.serverTime {
+				mutex.setServerExpiry(attemptAt, expiry)
+			}
+			if option.watchDog != nil {
+				mutex.runWatchDog(parentCtx)
+			}
+		}
+		return ok, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mutex, nil
+}
+
+// retryLock calls attempt until it acquires the lock of key as value, waiting between attempts as retryStrategy tells,
+// it returns ErrMutexLockFailed once retryStrategy gives up. If ctx has no deadline, the attempts end
+// when expiration passes, so a lock is not acquired after it would have expired.
+func (c *Client) retryLock(ctx context.Context, key, value string, expiration time.Duration, retryStrategy RetryStrategy, attempt func(ctx context.Context) (bool, error)) (retryCount int, err error) {
+	if _, ok := ctx.Deadline(); !ok {
 		var cancel context.CancelFunc
-		childCtx, cancel = context.WithDeadline(childCtx, time.Now().Add(expiration))
+		ctx, cancel = c.clock.WithDeadline(ctx, c.clock.Now().Add(expiration))
 		defer cancel()
 	}
 
-	var ticker *time.Ticker
 	for {
-		ok, err := c.lock(childCtx, key, value, expiration)
+		ok, err := attempt(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("c.lock error: %w", err)
+			return retryCount, err
 		}
 
 		if ok {
-			if option.watchDog != nil {
-				mutex.runWatchDog(parentCtx)
-			}
-			return mutex, nil
+			return retryCount, nil
 		}
 
-		retryTime := option.retryStrategy.NextRetryTime()
+		retryTime := retryStrategy.NextRetryTime()
 		if retryTime == 0 {
-			return nil, ErrMutexLockFailed
+			c.logger.Debug("redislock: lock is held by others", "key", key, "token", logToken(value), "retry_count", retryCount)
+			return retryCount, ErrMutexLockFailed
 		}
+		c.logger.Debug("redislock: lock is held by others, retry later", "key", key, "token", logToken(value), "retry_count", retryCount, "retry_time", retryTime)
 
-		if ticker == nil {
-			ticker = time.NewTicker(retryTime)
-			defer ticker.Stop()
-		} else {
-			ticker.Reset(retryTime)
+		if err = c.sleep(ctx, retryTime); err != nil {
+			c.logger.Debug("redislock: acquire lock cancelled", "key", key, "token", logToken(value), "retry_count", retryCount, "error", err)
+			return retryCount, err
 		}
+		retryCount++
+	}
+}
 
-		select {
-		case <-childCtx.Done():
-			return nil, childCtx.Err()
-		case <-ticker.C:
-		}
+// lock sets key to value if key does not exist,
+// fencingToken is the fencing token of the lock if fencing is enabled,
+// expiry is the expiry of the lock observed in redis if the client uses WithServerTime.
+func (c *Client) lock(ctx context.Context, key, value string, expiration time.Duration) (ok bool, fencingToken int64, expiry serverExpiry, err error) {
+	if c.serverTime {
+		return c.lockServerTime(ctx, key, value, expiration)
 	}
+
+	if c.waitReplicas > 0 {
+		ok, fencingToken, err = c.lockWait(ctx, key, value, expiration)
+		return ok, fencingToken, serverExpiry{}, err
+	}
+
+	if !c.fencing {
+		ok, err = c.backend.SetNX(ctx, key, value, expiration)
+		return ok, 0, serverExpiry{}, err
+	}
+
+	fencingToken, err = c.eval(ctx, luaLockWithFencing, []string{key, FencingKey(key)}, value, expiration.Milliseconds()).Int64()
+	if err != nil {
+		return false, 0, serverExpiry{}, err
+	}
+	return fencingToken != 0, fencingToken, serverExpiry{}, nil
 }
 
-func (c *Client) lock(ctx context.Context, key, value string, expiration time.Duration) (bool, error) {
-	return c.redisClient.SetNX(ctx, key, value, expiration).Result()
+// Holder returns the token of the holder of the lock of key, see Mutex.Token,
+// returns ErrMutexNotHeld if the lock of key is not held.
+func (c *Client) Holder(ctx context.Context, key string) (string, error) {
+	token, found, err := c.backend.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", ErrMutexNotHeld
+	}
+	return token, nil
+}
+
+// FencingKey returns the key of the fencing counter of key,
+// it is in the same redis cluster hash slot as key unless key contains
+// a "}" without being a valid hash tag.
+func FencingKey(key string) string {
+	return sameSlotKey(key, ":fencing")
+}
+
+// sameSlotKey returns key with suffix, in the same redis cluster hash slot as key
+// unless key contains a "}" without being a valid hash tag.
+func sameSlotKey(key, suffix string) string {
+	if start := strings.IndexByte(key, '{'); start != -1 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			// key has a hash tag, which is kept at the beginning.
+			return key + suffix
+		}
+	}
+
+	if strings.IndexByte(key, '}') != -1 {
+		return key + suffix
+	}
+	return "{" + key + "}" + suffix
 }
 
 // getValue returns a value that is unique to this client.
@@ -55,17 +55,17 @@ func TestNewClient(t *testing.T) {
 		{
 			"NewClientWithNothing",
 			actualOne,
-			&Client{redisClient: rdb, cipherKey: "-1", Cipher: actualOne.Cipher},
+			&Client{backend: goRedisBackend{client: rdb}, cipherKey: "-1", Cipher: actualOne.Cipher},
 		},
 		{
 			"NewClientWithCipherKey",
 			actualTwo,
-			&Client{redisClient: rdb, cipherKey: "11181114", Cipher: cipherTwo},
+			&Client{backend: goRedisBackend{client: rdb}, cipherKey: "11181114", Cipher: cipherTwo},
 		},
 		{
 			Name:     "NewClientWithCipher",
 			Actual:   actualThree,
-			Expected: &Client{redisClient: rdb, cipherKey: "-1", Cipher: cipherThree},
+			Expected: &Client{backend: goRedisBackend{client: rdb}, cipherKey: "-1", Cipher: cipherThree},
 		},
 	}
 
@@ -96,7 +96,7 @@ func TestNewDefaultClient(t *testing.T) {
 	}
 
 	// test cases
-	compareClient(t, &Client{redisClient: rdb, cipherKey: cipherKey, Cipher: cipher}, client)
+	compareClient(t, &Client{backend: goRedisBackend{client: rdb}, cipherKey: cipherKey, Cipher: cipher}, client)
 }
 
 func TestClient_TryLock(t *testing.T) {
@@ -356,8 +356,8 @@ func compareMutex(t *testing.T, expected *Mutex, actual *Mutex) {
 
 func compareClient(t *testing.T, expect, actual *Client) {
 	t.Helper()
-	if expect.redisClient != actual.redisClient {
-		t.Errorf("redis client is not equal,expected %v, got %v", expect.redisClient, actual.redisClient)
+	if expect.backend != actual.backend {
+		t.Errorf("backend is not equal,expected %v, got %v", expect.backend, actual.backend)
 	}
 
 	if expect.cipherKey != actual.cipherKey {
@@ -0,0 +1,51 @@
+package redislock
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the source of time used by redislock for deadlines, retries and the watch dog,
+// redislocktest provides a fake clock that tests advance by hand.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTimer returns a channel that receives the time once d has passed,
+	// and a function that stops the timer, it reports whether the timer was stopped before it fired.
+	NewTimer(d time.Duration) (<-chan time.Time, func() bool)
+	// WithDeadline returns a copy of ctx that is done when the clock reaches deadline.
+	WithDeadline(ctx context.Context, deadline time.Time) (context.Context, context.CancelFunc)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	timer := time.NewTimer(d)
+	return timer.C, timer.Stop
+}
+
+func (systemClock) WithDeadline(ctx context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	return context.WithDeadline(ctx, deadline)
+}
+
+// Clock returns the clock of the client, packages built on redislock measure their timing by it too.
+func (c *Client) Clock() Clock {
+	return c.clock
+}
+
+// sleep waits until d has passed on the clock of the client or ctx is done.
+func (c *Client) sleep(ctx context.Context, d time.Duration) error {
+	timer, stop := c.clock.NewTimer(d)
+	defer stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer:
+		return nil
+	}
+}
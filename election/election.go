@@ -0,0 +1,242 @@
+// Package election provides leader election based on the watch dog renewed redislock.Mutex.
+package election
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+)
+
+// DefaultRetryInterval is the default interval between two campaign attempts.
+const DefaultRetryInterval = time.Second
+
+// ErrNoLeader is returned by Leader when no candidate is leader.
+var ErrNoLeader = errors.New("no leader")
+
+// separator separates the id of the leader from the random part of its token.
+const separator = "\x00"
+
+// Election elects one leader among the candidates campaigning on the same key.
+type Election struct {
+	client        *redislock.Client
+	key           string
+	id            string        // id of the candidate, reported by Leader.
+	expiration    time.Duration // expiration of the leader lock, kept by a watch dog.
+	retryInterval time.Duration // interval between two campaign attempts.
+	onElected     func()        // called when the candidate becomes leader.
+	onStopped     func()        // called when the candidate stops being leader.
+	campaign      chan struct{} // held by the running Campaign, so campaigns do not race.
+
+	mu   sync.Mutex
+	term *term // current term, nil if the candidate is not leader.
+	lost chan struct{}
+}
+
+// term is the leadership of a candidate from being elected to resigning or losing the lock.
+type term struct {
+	mutex    *redislock.Mutex
+	cancel   context.CancelFunc // stops the watch dog of mutex.
+	resigned chan struct{}
+}
+
+// New creates a new Election of key, the id of the candidate is "<hostname>-<pid>" by default.
+func New(client *redislock.Client, key string, options ...Option) *Election {
+	hostname, _ := os.Hostname()
+	e := &Election{
+		client:        client,
+		key:           key,
+		id:            fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		expiration:    redislock.DefaultExpiration,
+		retryInterval: DefaultRetryInterval,
+		campaign:      make(chan struct{}, 1),
+	}
+
+	for _, option := range options {
+		option(e)
+	}
+
+	return e
+}
+
+type Option func(election *Election)
+
+// WithID sets the id of the candidate reported by Leader, it must not contain a NUL byte.
+func WithID(id string) Option {
+	return func(election *Election) {
+		election.id = id
+	}
+}
+
+// WithExpiration sets the expiration of the leader lock, which is kept by a watch dog.
+func WithExpiration(expiration time.Duration) Option {
+	return func(election *Election) {
+		election.expiration = expiration
+	}
+}
+
+// WithRetryInterval sets the interval between two campaign attempts.
+func WithRetryInterval(retryInterval time.Duration) Option {
+	return func(election *Election) {
+		election.retryInterval = retryInterval
+	}
+}
+
+// WithOnElected sets the callback called when the candidate becomes leader.
+func WithOnElected(onElected func()) Option {
+	return func(election *Election) {
+		election.onElected = onElected
+	}
+}
+
+// WithOnStopped sets the callback called when the candidate stops being leader,
+// by resigning or losing the leadership.
+func WithOnStopped(onStopped func()) Option {
+	return func(election *Election) {
+		election.onStopped = onStopped
+	}
+}
+
+// Campaign blocks until the candidate is elected or ctx is done,
+// it returns nil at once if the candidate is already leader.
+// The leadership lasts after ctx is done, until Resign or the leadership is lost,
+// an attempt in flight when ctx is done is completed and Campaign returns nil if it elects the candidate.
+// Concurrent calls campaign one after another.
+func (e *Election) Campaign(ctx context.Context) error {
+	select {
+	case e.campaign <- struct{}{}:
+		defer func() { <-e.campaign }()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if e.IsLeader() {
+		return nil
+	}
+
+	clock := e.client.Clock()
+	for {
+		// lockCtx outlives ctx, only the term cancels it, so the watch dog keeps the lock until the term ends.
+		lockCtx, cancel := context.WithCancel(context.Background())
+		mutex, err := e.elect(lockCtx)
+		if err == nil {
+			e.start(&term{mutex: mutex, cancel: cancel, resigned: make(chan struct{})})
+			return nil
+		}
+		cancel()
+
+		timer, stopTimer := clock.NewTimer(e.retryInterval)
+		select {
+		case <-ctx.Done():
+			stopTimer()
+			return ctx.Err()
+		case <-timer:
+		}
+	}
+}
+
+// start begins term t of the elected candidate.
+func (e *Election) start(t *term) {
+	lost := make(chan struct{})
+
+	e.mu.Lock()
+	e.term = t
+	e.lost = lost
+	e.mu.Unlock()
+
+	if e.onElected != nil {
+		e.onElected()
+	}
+
+	go e.watch(t, lost)
+}
+
+// elect acquires the leader lock as a token carrying the id of the candidate,
+// so Leader can tell who the leader is, the lock is kept by a watch dog.
+func (e *Election) elect(ctx context.Context) (*redislock.Mutex, error) {
+	token, err := e.client.NewToken()
+	if err != nil {
+		return nil, err
+	}
+	return e.client.TryLockWithTokenAndWatchDog(ctx, e.key, e.id+separator+token, redislock.NewWatchDog(e.expiration))
+}
+
+// watch ends the term when it is resigned or the leadership is lost.
+func (e *Election) watch(t *term, lost chan struct{}) {
+	isLost := false
+	select {
+	case <-t.mutex.Lost():
+		isLost = true
+	case <-t.resigned:
+	}
+	t.cancel()
+
+	e.mu.Lock()
+	if e.term == t {
+		e.term = nil
+	}
+	e.mu.Unlock()
+
+	if isLost {
+		close(lost)
+	}
+
+	if e.onStopped != nil {
+		e.onStopped()
+	}
+}
+
+// Resign gives up the leadership, it returns nil if the candidate is not leader.
+func (e *Election) Resign(ctx context.Context) error {
+	e.mu.Lock()
+	t := e.term
+	e.term = nil
+	e.mu.Unlock()
+
+	if t == nil {
+		return nil
+	}
+
+	err := t.mutex.Unlock(ctx)
+	close(t.resigned)
+	return err
+}
+
+// Leader returns the id of the current leader of key observed in redis,
+// returns ErrNoLeader if no candidate is leader.
+func (e *Election) Leader(ctx context.Context) (string, error) {
+	token, err := e.client.Holder(ctx, e.key)
+	if redislock.IsMutexNotHeld(err) {
+		return "", ErrNoLeader
+	} else if err != nil {
+		return "", err
+	}
+
+	id, _, found := strings.Cut(token, separator)
+	if !found {
+		// the lock is not held by a candidate of an election.
+		return "", ErrNoLeader
+	}
+	return id, nil
+}
+
+// IsLeader returns true if the candidate is leader.
+func (e *Election) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.term != nil
+}
+
+// LeadershipLost returns a channel that is closed when the leadership of the latest term is lost,
+// for example the watch dog can not reach redis, it is not closed by Resign.
+// It returns nil before the candidate is elected for the first time.
+func (e *Election) LeadershipLost() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lost
+}
@@ -9,6 +9,17 @@ var (
 	ErrMutexLockFailed                = errors.New("mutex locks failed")
 	ErrMutexNotHeld                   = errors.New("mutex not held")
 	ErrMutexNotInitialized            = errors.New("mutex not initialized")
+	ErrRWMutexUpgradeConflict         = errors.New("rw mutex upgrade conflict")
+	ErrSingleFlightFailed             = errors.New("single flight failed")
+	ErrIdempotencyInProgress          = errors.New("idempotency key in progress")
+	ErrScriptLoaderUnsupported        = errors.New("backend does not support script loading")
+	ErrFunctionsUnsupported           = errors.New("backend does not support functions")
+	ErrNotEnoughReplicas              = errors.New("not enough replicas acknowledged")
+	ErrWaitUnsupported                = errors.New("backend does not support wait")
+	ErrInvalidExpiration              = errors.New("expiration must be positive")
+	ErrBarrierBroken                  = errors.New("barrier broken")
+	ErrLatchNotSet                    = errors.New("latch not set or expired")
+	ErrInvalidPartitions              = errors.New("partitions must be positive")
 )
 
 // IsWatchDogExpiredNotLessThanZero returns true if err is ErrWatchDogExpiredNotLessThanZero.
@@ -40,3 +51,58 @@ func IsMutexNotHeld(err error) bool {
 func IsMutexNotInitialized(err error) bool {
 	return errors.Is(err, ErrMutexNotInitialized)
 }
+
+// IsRWMutexUpgradeConflict returns true if err is ErrRWMutexUpgradeConflict.
+func IsRWMutexUpgradeConflict(err error) bool {
+	return errors.Is(err, ErrRWMutexUpgradeConflict)
+}
+
+// IsSingleFlightFailed returns true if err is ErrSingleFlightFailed.
+func IsSingleFlightFailed(err error) bool {
+	return errors.Is(err, ErrSingleFlightFailed)
+}
+
+// IsIdempotencyInProgress returns true if err is ErrIdempotencyInProgress.
+func IsIdempotencyInProgress(err error) bool {
+	return errors.Is(err, ErrIdempotencyInProgress)
+}
+
+// IsScriptLoaderUnsupported returns true if err is ErrScriptLoaderUnsupported.
+func IsScriptLoaderUnsupported(err error) bool {
+	return errors.Is(err, ErrScriptLoaderUnsupported)
+}
+
+// IsFunctionsUnsupported returns true if err is ErrFunctionsUnsupported.
+func IsFunctionsUnsupported(err error) bool {
+	return errors.Is(err, ErrFunctionsUnsupported)
+}
+
+// IsNotEnoughReplicas returns true if err is ErrNotEnoughReplicas.
+func IsNotEnoughReplicas(err error) bool {
+	return errors.Is(err, ErrNotEnoughReplicas)
+}
+
+// IsWaitUnsupported returns true if err is ErrWaitUnsupported.
+func IsWaitUnsupported(err error) bool {
+	return errors.Is(err, ErrWaitUnsupported)
+}
+
+// IsInvalidExpiration returns true if err is ErrInvalidExpiration.
+func IsInvalidExpiration(err error) bool {
+	return errors.Is(err, ErrInvalidExpiration)
+}
+
+// IsBarrierBroken returns true if err is ErrBarrierBroken.
+func IsBarrierBroken(err error) bool {
+	return errors.Is(err, ErrBarrierBroken)
+}
+
+// IsLatchNotSet returns true if err is ErrLatchNotSet.
+func IsLatchNotSet(err error) bool {
+	return errors.Is(err, ErrLatchNotSet)
+}
+
+// IsInvalidPartitions returns true if err is ErrInvalidPartitions.
+func IsInvalidPartitions(err error) bool {
+	return errors.Is(err, ErrInvalidPartitions)
+}
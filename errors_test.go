@@ -142,3 +142,256 @@ func TestIsMutexNotInitialized(t *testing.T) {
 		})
 	}
 }
+
+func TestIsRWMutexUpgradeConflict(t *testing.T) {
+	type args struct {
+		err error
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"IsRWMutexUpgradeConflict", args{ErrRWMutexUpgradeConflict}, true},
+		{"IsRWMutexUpgradeConflictWithWrap", args{fmt.Errorf("errors.Wrap %w", ErrRWMutexUpgradeConflict)}, true},
+		{"NotIsRWMutexUpgradeConflict", args{ErrMutexNotHeld}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRWMutexUpgradeConflict(tt.args.err); got != tt.want {
+				t.Errorf("IsRWMutexUpgradeConflict() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSingleFlightFailed(t *testing.T) {
+	type args struct {
+		err error
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"IsSingleFlightFailed", args{ErrSingleFlightFailed}, true},
+		{"IsSingleFlightFailedWithWrap", args{fmt.Errorf("errors.Wrap %w", ErrSingleFlightFailed)}, true},
+		{"NotIsSingleFlightFailed", args{ErrMutexNotHeld}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSingleFlightFailed(tt.args.err); got != tt.want {
+				t.Errorf("IsSingleFlightFailed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsIdempotencyInProgress(t *testing.T) {
+	type args struct {
+		err error
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"IsIdempotencyInProgress", args{ErrIdempotencyInProgress}, true},
+		{"IsIdempotencyInProgressWithWrap", args{fmt.Errorf("errors.Wrap %w", ErrIdempotencyInProgress)}, true},
+		{"NotIsIdempotencyInProgress", args{ErrMutexLockFailed}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsIdempotencyInProgress(tt.args.err); got != tt.want {
+				t.Errorf("IsIdempotencyInProgress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsScriptLoaderUnsupported(t *testing.T) {
+	type args struct {
+		err error
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"IsScriptLoaderUnsupported", args{ErrScriptLoaderUnsupported}, true},
+		{"IsScriptLoaderUnsupportedWithWrap", args{fmt.Errorf("errors.Wrap %w", ErrScriptLoaderUnsupported)}, true},
+		{"NotIsScriptLoaderUnsupported", args{ErrMutexLockFailed}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsScriptLoaderUnsupported(tt.args.err); got != tt.want {
+				t.Errorf("IsScriptLoaderUnsupported() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsFunctionsUnsupported(t *testing.T) {
+	type args struct {
+		err error
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"IsFunctionsUnsupported", args{ErrFunctionsUnsupported}, true},
+		{"IsFunctionsUnsupportedWithWrap", args{fmt.Errorf("errors.Wrap %w", ErrFunctionsUnsupported)}, true},
+		{"NotIsFunctionsUnsupported", args{ErrMutexLockFailed}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsFunctionsUnsupported(tt.args.err); got != tt.want {
+				t.Errorf("IsFunctionsUnsupported() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsNotEnoughReplicas(t *testing.T) {
+	type args struct {
+		err error
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"IsNotEnoughReplicas", args{ErrNotEnoughReplicas}, true},
+		{"IsNotEnoughReplicasWithWrap", args{fmt.Errorf("errors.Wrap %w", ErrNotEnoughReplicas)}, true},
+		{"NotIsNotEnoughReplicas", args{ErrMutexLockFailed}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsNotEnoughReplicas(tt.args.err); got != tt.want {
+				t.Errorf("IsNotEnoughReplicas() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsWaitUnsupported(t *testing.T) {
+	type args struct {
+		err error
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"IsWaitUnsupported", args{ErrWaitUnsupported}, true},
+		{"IsWaitUnsupportedWithWrap", args{fmt.Errorf("errors.Wrap %w", ErrWaitUnsupported)}, true},
+		{"NotIsWaitUnsupported", args{ErrMutexLockFailed}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsWaitUnsupported(tt.args.err); got != tt.want {
+				t.Errorf("IsWaitUnsupported() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsInvalidExpiration(t *testing.T) {
+	type args struct {
+		err error
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"IsInvalidExpiration", args{ErrInvalidExpiration}, true},
+		{"IsInvalidExpirationWithWrap", args{fmt.Errorf("errors.Wrap %w", ErrInvalidExpiration)}, true},
+		{"NotIsInvalidExpiration", args{ErrMutexLockFailed}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsInvalidExpiration(tt.args.err); got != tt.want {
+				t.Errorf("IsInvalidExpiration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsBarrierBroken(t *testing.T) {
+	type args struct {
+		err error
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"IsBarrierBroken", args{ErrBarrierBroken}, true},
+		{"IsBarrierBrokenWithWrap", args{fmt.Errorf("errors.Wrap %w", ErrBarrierBroken)}, true},
+		{"NotIsBarrierBroken", args{ErrMutexLockFailed}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBarrierBroken(tt.args.err); got != tt.want {
+				t.Errorf("IsBarrierBroken() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsLatchNotSet(t *testing.T) {
+	type args struct {
+		err error
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"IsLatchNotSet", args{ErrLatchNotSet}, true},
+		{"IsLatchNotSetWithWrap", args{fmt.Errorf("errors.Wrap %w", ErrLatchNotSet)}, true},
+		{"NotIsLatchNotSet", args{ErrBarrierBroken}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsLatchNotSet(tt.args.err); got != tt.want {
+				t.Errorf("IsLatchNotSet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsInvalidPartitions(t *testing.T) {
+	type args struct {
+		err error
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"IsInvalidPartitions", args{ErrInvalidPartitions}, true},
+		{"IsInvalidPartitionsWithWrap", args{fmt.Errorf("errors.Wrap %w", ErrInvalidPartitions)}, true},
+		{"NotIsInvalidPartitions", args{ErrMutexLockFailed}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsInvalidPartitions(tt.args.err); got != tt.want {
+				t.Errorf("IsInvalidPartitions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
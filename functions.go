@@ -0,0 +1,104 @@
+package redislock
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// LibraryName is the name of the function library of redislock, see WithFunctions.
+const LibraryName = "redislock"
+
+// LibraryVersion is the version of the function library, it increases whenever a script changes.
+// The functions of a version are named redislock_v<version>_<script>,
+// so clients of an older version fall back to scripts instead of calling changed functions.
+const LibraryVersion = 9
+
+// functionVersion is the function returning the version of the loaded library.
+const functionVersion = LibraryName + "_version"
+
+// library is the code of the function library, it registers a function for each script.
+var library = newLibrary()
+
+func newLibrary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#!lua name=%s\n", LibraryName)
+	fmt.Fprintf(&b, "redis.register_function{function_name='%s', callback=function() return %d end, flags={'no-writes'}}\n",
+		functionVersion, LibraryVersion)
+	for _, name := range scriptNames() {
+		fmt.Fprintf(&b, "redis.register_function('%s', function(KEYS, ARGV)\n%s\nend)\n",
+			functionName(name), strings.TrimSpace(scripts[name].Source()))
+	}
+	return b.String()
+}
+
+// functionName returns the name of the function of the script named name.
+func functionName(name string) string {
+	return fmt.Sprintf("%s_v%d_%s", LibraryName, LibraryVersion, name)
+}
+
+// fcall calls script as a function of the library, the library is loaded if the function is not found.
+// ok is false if the function cannot be called, then script is to be run by EVAL.
+func (c *Client) fcall(ctx context.Context, caller FunctionCaller, script *Script, keys []string, args ...interface{}) (reply interface{}, ok bool, err error) {
+	function := functionName(script.name)
+	reply, err = caller.FCall(ctx, function, keys, args...)
+	if isFunctionNotFound(err) {
+		if !atomic.CompareAndSwapInt32(&c.loadingLibrary, 0, 1) {
+			return nil, false, nil
+		}
+		err = c.loadLibrary(ctx, caller)
+		atomic.StoreInt32(&c.loadingLibrary, 0)
+		if err != nil {
+			c.logger.Error("redislock: load function library failed", "error", err)
+			return nil, false, nil
+		}
+		if atomic.LoadInt32(&c.newerLibrary) == 1 {
+			return nil, false, nil
+		}
+
+		// a newer library may be loaded, which does not have the function of this version.
+		if reply, err = caller.FCall(ctx, function, keys, args...); isFunctionNotFound(err) {
+			return nil, false, nil
+		}
+	}
+
+	if IsFunctionsUnsupported(err) || isUnknownCommand(err) {
+		atomic.StoreInt32(&c.noFunctions, 1)
+		c.logger.Warn("redislock: functions are not supported, using scripts", "error", err)
+		return nil, false, nil
+	}
+	return reply, true, err
+}
+
+// loadLibrary loads the library unless the same or a newer version is loaded.
+// A newer version is remembered, the client runs scripts from then on instead of probing the library.
+func (c *Client) loadLibrary(ctx context.Context, caller FunctionCaller) error {
+	version, err := caller.FCall(ctx, functionVersion, nil)
+	if err != nil && !isFunctionNotFound(err) {
+		return fmt.Errorf("caller.FCall %s error: %w", functionVersion, err)
+	}
+	if loaded, ok := version.(int64); ok && loaded >= LibraryVersion {
+		if loaded > LibraryVersion {
+			atomic.StoreInt32(&c.newerLibrary, 1)
+			c.logger.Warn("redislock: a newer function library is loaded, using scripts", "library", LibraryName, "version", loaded)
+		}
+		return nil
+	}
+
+	c.logger.Info("redislock: loading function library", "library", LibraryName, "version", LibraryVersion)
+	if err = caller.FunctionLoad(ctx, library); err != nil {
+		return fmt.Errorf("caller.FunctionLoad error: %w", err)
+	}
+	return nil
+}
+
+// isFunctionNotFound reports whether err is the error of redis calling a function which is not loaded.
+func isFunctionNotFound(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "Function not found")
+}
+
+// isUnknownCommand reports whether err is the error of redis to a command it does not know, such as FCALL before redis 7.
+func isUnknownCommand(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "unknown command")
+}
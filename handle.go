@@ -0,0 +1,90 @@
+package redislock
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Handle is the portable form of a held Mutex, it can be serialized and
+// passed to another process, which resumes the lock by Client.Resume.
+type Handle struct {
+	Key          string        `json:"key"`
+	Token        []byte        `json:"token"`         // value of the lock, it is binary.
+	FencingToken int64         `json:"fencing_token"` // 0 if fencing is not enabled.
+	Expiration   time.Duration `json:"expiration"`
+	AcquiredAt   time.Time     `json:"acquired_at"`
+	ExpiresAt    time.Time     `json:"expires_at"` // last known deadline of the lock.
+}
+
+// Export stops the watch dog of the mutex and returns its handle,
+// the mutex should not be used after Export, the lock is kept by the one resuming the handle.
+func (m *Mutex) Export() (*Handle, error) {
+	if m == nil {
+		return nil, ErrMutexNotInitialized
+	}
+
+	if m.watchDog != nil {
+		m.stopWatchDog()
+	}
+
+	return &Handle{
+		Key:          m.key,
+		Token:        []byte(m.value),
+		FencingToken: m.fencingToken,
+		Expiration:   m.getExpiration(),
+		AcquiredAt:   m.acquiredAt,
+		ExpiresAt:    m.Deadline(),
+	}, nil
+}
+
+// Resume verifies the lock of handle is still held and returns a Mutex holding it,
+// returns ErrMutexNotHeld if the lock is no longer held.
+func (c *Client) Resume(ctx context.Context, handle *Handle) (*Mutex, error) {
+	return c.resume(ctx, handle, nil)
+}
+
+// ResumeWithWatchDog is like Resume, the returned Mutex is kept by watchDog,
+// if watchDog is nil, a default watch dog is used.
+func (c *Client) ResumeWithWatchDog(ctx context.Context, handle *Handle, watchDog *WatchDog) (*Mutex, error) {
+	watchDog, err := checkWatchDogReturnWatchDog(watchDog)
+	if err != nil {
+		return nil, fmt.Errorf("checkWatchDogReturnWatchDog error: %w", err)
+	}
+
+	return c.resume(ctx, handle, watchDog)
+}
+
+func (c *Client) resume(ctx context.Context, handle *Handle, watchDog *WatchDog) (*Mutex, error) {
+	if handle == nil {
+		return nil, ErrMutexNotHeld
+	}
+
+	expiration := handle.Expiration
+	if watchDog != nil {
+		expiration = watchDog.expiration
+	}
+
+	mutex := newMutex(c, handle.Key, string(handle.Token), expiration, NewNoRetry())
+	mutex.acquiredAt = handle.AcquiredAt
+	mutex.fencingToken = handle.FencingToken
+
+	now := c.clock.Now()
+	ttl, err := mutex.TTL(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("mutex.TTL error: %w", err)
+	}
+	mutex.deadline = now.Add(ttl)
+
+	if watchDog != nil {
+		// the remaining ttl may be shorter than the first renewal of the watch dog.
+		if err = mutex.pexpire(ctx, expiration); err != nil {
+			return nil, fmt.Errorf("mutex.pexpire error: %w", err)
+		}
+
+		mutex.setWatchDog(watchDog)
+		mutex.runWatchDog(ctx)
+	}
+
+	return mutex, nil
+}
@@ -0,0 +1,127 @@
+package redislock
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestClient_Resume(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	// init redislock clients of two processes
+	clientOne, err := NewClient(rdb, WithFencing())
+	if err != nil {
+		t.Fatalf("clientOne NewClient error:[%v]", err)
+	}
+
+	clientTwo, err := NewClient(rdb, WithFencing())
+	if err != nil {
+		t.Fatalf("clientTwo NewClient error:[%v]", err)
+	}
+	key := "testOne"
+	defer teardown(t, rdb, []string{key, FencingKey(key)})
+
+	ctx := context.Background()
+
+	mutex, err := clientOne.TryLock(ctx, key, -1)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+
+	handle, err := mutex.Export()
+	if err != nil {
+		t.Fatalf("Export error:[%v]", err)
+	}
+
+	data, err := json.Marshal(handle)
+	if err != nil {
+		t.Fatalf("json.Marshal error:[%v]", err)
+	}
+
+	var actual Handle
+	if err = json.Unmarshal(data, &actual); err != nil {
+		t.Fatalf("json.Unmarshal error:[%v]", err)
+	}
+
+	resumedOne, err := clientTwo.Resume(ctx, &actual)
+	if err != nil {
+		t.Fatalf("Resume error:[%v]", err)
+	}
+
+	resumedTwo, err := clientTwo.ResumeWithWatchDog(ctx, &actual, NewWatchDog(10*time.Second))
+	if err != nil {
+		t.Fatalf("ResumeWithWatchDog error:[%v]", err)
+	}
+
+	// test cases
+	cases := []struct {
+		Name       string
+		Actual     *Mutex
+		Expiration time.Duration
+	}{
+		{"Resume", resumedOne, DefaultExpiration},
+		{"ResumeWithWatchDog", resumedTwo, 10 * time.Second},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			if c.Actual.Key() != mutex.Key() {
+				t.Errorf("key is not equal,expected %v, got %v", mutex.Key(), c.Actual.Key())
+			}
+
+			if c.Actual.Token() != mutex.Token() {
+				t.Errorf("token is not equal,expected %v, got %v", mutex.Token(), c.Actual.Token())
+			}
+
+			if c.Actual.FencingToken() == 0 || c.Actual.FencingToken() != mutex.FencingToken() {
+				t.Errorf("fencing token is not equal,expected %v, got %v", mutex.FencingToken(), c.Actual.FencingToken())
+			}
+
+			if c.Actual.Expiration() != c.Expiration {
+				t.Errorf("expiration is not equal,expected %v, got %v", c.Expiration, c.Actual.Expiration())
+			}
+
+			if !c.Actual.AcquiredAt().Equal(mutex.AcquiredAt()) {
+				t.Errorf("acquiredAt is not equal,expected %v, got %v", mutex.AcquiredAt(), c.Actual.AcquiredAt())
+			}
+		})
+	}
+
+	if err = resumedTwo.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+
+	t.Run("ResumeNotHeld", func(t *testing.T) {
+		if _, err := clientTwo.Resume(ctx, &actual); !IsMutexNotHeld(err) {
+			t.Errorf("Resume error is not equal,expected %v, got %v", ErrMutexNotHeld, err)
+		}
+	})
+}
+
+func TestFencingKey(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{"FencingKey", "XdpCs", "{XdpCs}:fencing"},
+		{"FencingKeyWithHashTag", "{XdpCs}:lock", "{XdpCs}:lock:fencing"},
+		{"FencingKeyWithEmptyHashTag", "{}XdpCs", "{}XdpCs:fencing"},
+		{"FencingKeyWithOpenBrace", "Xdp{Cs", "{Xdp{Cs}:fencing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FencingKey(tt.key); got != tt.want {
+				t.Errorf("FencingKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package redislock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DefaultResponseTTL is the default time a response of an idempotency key is kept.
+const DefaultResponseTTL = 24 * time.Hour
+
+// Response is the response of a request stored under its idempotency key.
+type Response struct {
+	Status int                 `json:"status"`
+	Header map[string][]string `json:"header,omitempty"` // http.Header can be converted to it.
+	Body   []byte              `json:"body,omitempty"`
+}
+
+// Idempotency handles each idempotency key once across processes:
+// the request handled first locks the key and stores its response after success,
+// the following requests get the stored response.
+type Idempotency struct {
+	client       *Client
+	responseTTL  time.Duration // time a response is kept.
+	wait         bool          // duplicates in progress wait for the response, or get ErrIdempotencyInProgress.
+	pollInterval time.Duration // interval waiting duplicates check the response.
+}
+
+// NewIdempotency creates a new Idempotency, duplicates in progress get ErrIdempotencyInProgress by default.
+func (c *Client) NewIdempotency(options ...IdempotencyOption) *Idempotency {
+	i := &Idempotency{
+		client:       c,
+		responseTTL:  DefaultResponseTTL,
+		pollInterval: DefaultPollInterval,
+	}
+
+	for _, option := range options {
+		option(i)
+	}
+
+	return i
+}
+
+type IdempotencyOption func(idempotency *Idempotency)
+
+// WithResponseTTL sets the time a response is kept.
+func WithResponseTTL(responseTTL time.Duration) IdempotencyOption {
+	return func(idempotency *Idempotency) {
+		idempotency.responseTTL = responseTTL
+	}
+}
+
+// WithWaitInProgress makes duplicates in progress wait for the response,
+// checking it every pollInterval in case notifications are not supported or missed.
+func WithWaitInProgress(pollInterval time.Duration) IdempotencyOption {
+	return func(idempotency *Idempotency) {
+		idempotency.wait = true
+		idempotency.pollInterval = pollInterval
+	}
+}
+
+// Do returns the stored response of key with replayed true if there is one,
+// otherwise it locks key, calls handle and stores its response if handle succeeds.
+// If handle fails, nothing is stored and a following request handles key again.
+// If another request of key is in progress, Do waits for it or returns ErrIdempotencyInProgress.
+// The ctx of handle is done when the lock is lost, and the response of a request which lost the lock
+// is not stored, it gets an error wrapping ErrMutexNotHeld instead.
+func (i *Idempotency) Do(ctx context.Context, key string, handle func(ctx context.Context) (*Response, error)) (response *Response, replayed bool, err error) {
+	var notify <-chan struct{}
+	subscribed := false
+	for {
+		response, err = i.response(ctx, key)
+		if err != nil || response != nil {
+			return response, response != nil, err
+		}
+
+		mutex, err := i.client.acquire(ctx, i.lockKey(key), nil)
+		if err == nil {
+			return i.handle(ctx, key, mutex, handle)
+		} else if !IsMutexLockFailed(err) {
+			return nil, false, err
+		}
+
+		if !i.wait {
+			return nil, false, ErrIdempotencyInProgress
+		}
+
+		if !subscribed {
+			// subscribe only before waiting, so requests finding the response hold no connection.
+			var closeSubscription func()
+			notify, closeSubscription, err = i.client.backend.Subscribe(ctx, i.channel(key))
+			if err != nil {
+				return nil, false, fmt.Errorf("i.client.subscribe error: %w", err)
+			}
+			defer closeSubscription()
+			subscribed = true
+
+			// the response may be stored before the subscription, check it again at once.
+			continue
+		}
+
+		if err = i.client.waitNotified(ctx, notify, i.pollInterval); err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+// handle calls handle holding mutex and stores its response.
+func (i *Idempotency) handle(ctx context.Context, key string, mutex *Mutex, handle func(ctx context.Context) (*Response, error)) (_ *Response, replayed bool, err error) {
+	defer func() {
+		releaseCtx, cancel := releaseContext(ctx)
+		defer cancel()
+		if unlockErr := mutex.Unlock(releaseCtx); unlockErr != nil && err == nil {
+			err = fmt.Errorf("mutex.Unlock error: %w", unlockErr)
+		}
+	}()
+
+	// the response may be stored between checking it and acquiring the lock.
+	response, err := i.response(ctx, key)
+	if err != nil || response != nil {
+		return response, response != nil, err
+	}
+
+	lockCtx, cancel := mutex.lockContext(ctx)
+	defer cancel()
+	response, err = handle(lockCtx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	stored, err := json.Marshal(response)
+	if err != nil {
+		return nil, false, fmt.Errorf("json.Marshal error: %w", err)
+	}
+
+	published, err := i.client.eval(ctx, luaPublishHeldResult, []string{mutex.key, i.responseKey(key)},
+		mutex.value, stored, i.responseTTL.Milliseconds(), i.channel(key)).Int()
+	if err == nil && published == 0 {
+		// a duplicate may be handling key, its response is stored instead.
+		err = ErrMutexNotHeld
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("store response error: %w", err)
+	}
+	return response, false, nil
+}
+
+// response returns the stored response of key, nil if there is no response.
+func (i *Idempotency) response(ctx context.Context, key string) (*Response, error) {
+	stored, found, err := i.client.backend.Get(ctx, i.responseKey(key))
+	if err != nil || !found {
+		return nil, err
+	}
+
+	response := &Response{}
+	if err = json.Unmarshal([]byte(stored), response); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal error: %w", err)
+	}
+	return response, nil
+}
+
+// lockKey returns the key of the lock of the request in progress.
+func (i *Idempotency) lockKey(key string) string {
+	return sameSlotKey(key, ":lock")
+}
+
+// responseKey returns the key of the stored response, which is in the slot of the lock key,
+// so the response is stored in one script with the check of the lock.
+func (i *Idempotency) responseKey(key string) string {
+	return sameSlotKey(key, ":response")
+}
+
+// channel returns the channel notified when the response is stored.
+func (i *Idempotency) channel(key string) string {
+	return key + ":stored"
+}
@@ -0,0 +1,125 @@
+package redislock
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestIdempotency_Do(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	// init redislock client
+	client, err := NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+	keyOne := "testOne"
+	keyTwo := "testTwo"
+	defer teardown(t, rdb, []string{"{" + keyOne + "}:response", "{" + keyOne + "}:lock", "{" + keyTwo + "}:response", "{" + keyTwo + "}:lock"})
+
+	ctx := context.Background()
+	idempotency := client.NewIdempotency()
+	expected := &Response{Status: http.StatusCreated, Header: http.Header{"X-Payment": {"1118"}}, Body: []byte("paid")}
+
+	t.Run("HandleFailed", func(t *testing.T) {
+		errHandle := errors.New("handle failed")
+		_, _, err := idempotency.Do(ctx, keyOne, func(ctx context.Context) (*Response, error) {
+			return nil, errHandle
+		})
+		if !errors.Is(err, errHandle) {
+			t.Errorf("Do error is not equal,expected %v, got %v", errHandle, err)
+		}
+	})
+
+	// test cases
+	cases := []struct {
+		Name     string
+		Replayed bool
+	}{
+		{"Handled", false},
+		{"Replayed", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			response, replayed, err := idempotency.Do(ctx, keyOne, func(ctx context.Context) (*Response, error) {
+				return expected, nil
+			})
+			if err != nil {
+				t.Fatalf("Do error:[%v]", err)
+			}
+
+			if replayed != c.Replayed {
+				t.Errorf("replayed is not equal,expected %v, got %v", c.Replayed, replayed)
+			}
+
+			compareResponse(t, expected, response)
+		})
+	}
+
+	t.Run("InProgress", func(t *testing.T) {
+		started := make(chan struct{})
+		done := make(chan error, 1)
+		go func() {
+			_, _, err := idempotency.Do(ctx, keyTwo, func(ctx context.Context) (*Response, error) {
+				close(started)
+				time.Sleep(200 * time.Millisecond)
+				return expected, nil
+			})
+			done <- err
+		}()
+
+		select {
+		case <-started:
+		case err := <-done:
+			t.Fatalf("Do error:[%v]", err)
+		}
+
+		_, _, err := idempotency.Do(ctx, keyTwo, func(ctx context.Context) (*Response, error) {
+			return nil, errors.New("duplicate handled")
+		})
+		if !IsIdempotencyInProgress(err) {
+			t.Errorf("Do error is not equal,expected %v, got %v", ErrIdempotencyInProgress, err)
+		}
+
+		response, replayed, err := client.NewIdempotency(WithWaitInProgress(time.Second)).Do(ctx, keyTwo, func(ctx context.Context) (*Response, error) {
+			return nil, errors.New("duplicate handled")
+		})
+		if err != nil {
+			t.Fatalf("Do error:[%v]", err)
+		}
+
+		if !replayed {
+			t.Errorf("waiting duplicate is not replayed")
+		}
+
+		compareResponse(t, expected, response)
+		if err = <-done; err != nil {
+			t.Fatalf("Do error:[%v]", err)
+		}
+	})
+}
+
+func compareResponse(t *testing.T, expected, actual *Response) {
+	t.Helper()
+	if expected.Status != actual.Status {
+		t.Errorf("status is not equal,expected %v, got %v", expected.Status, actual.Status)
+	}
+
+	if http.Header(expected.Header).Get("X-Payment") != http.Header(actual.Header).Get("X-Payment") {
+		t.Errorf("header is not equal,expected %v, got %v", expected.Header, actual.Header)
+	}
+
+	if string(expected.Body) != string(actual.Body) {
+		t.Errorf("body is not equal,expected %s, got %s", expected.Body, actual.Body)
+	}
+}
@@ -0,0 +1,117 @@
+package redislock
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// CountDownLatch is a distributed count down latch based on redis,
+// waiters block until the count reaches zero. The open latch is kept for its expiration,
+// a latch which is not set or has expired is not taken for open: Await and CountDown return ErrLatchNotSet.
+// The waiters keep the latch from expiring, so setup tasks may outlast the expiration while someone waits.
+type CountDownLatch struct {
+	client       *Client
+	key          string
+	expiration   time.Duration // refreshed by every count down and waiter, so abandoned latches expire.
+	pollInterval time.Duration // interval waiters check and refresh the latch.
+}
+
+// NewCountDownLatch creates a new CountDownLatch of key.
+func (c *Client) NewCountDownLatch(key string, expiration time.Duration) *CountDownLatch {
+	pollInterval := DefaultPollInterval
+	// waiters refresh the latch when they check it, which must happen before it expires.
+	if pollInterval > expiration/3 {
+		pollInterval = expiration / 3
+	}
+
+	return &CountDownLatch{
+		client:       c,
+		key:          key,
+		expiration:   expiration,
+		pollInterval: pollInterval,
+	}
+}
+
+// TrySetCount sets the count of the latch if the latch is open, not set or has expired,
+// it returns false if the latch is counting down.
+func (l *CountDownLatch) TrySetCount(ctx context.Context, count int64) (bool, error) {
+	status, err := l.client.eval(ctx, luaLatchSetCount, []string{l.key}, count, l.expiration.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return status == 1, nil
+}
+
+// CountDown decreases the count of the latch, waiters are released when it reaches zero.
+// It returns ErrLatchNotSet if the latch is not set or has expired.
+func (l *CountDownLatch) CountDown(ctx context.Context) error {
+	count, err := l.client.eval(ctx, luaLatchCountDown, []string{l.key}, l.expiration.Milliseconds(), l.channel()).Int64()
+	if err != nil {
+		return err
+	}
+
+	// -1 means the latch is not set or has expired.
+	if count == -1 {
+		return ErrLatchNotSet
+	}
+	return nil
+}
+
+// Count returns the count of the latch, 0 if the latch is open,
+// it returns ErrLatchNotSet if the latch is not set or has expired.
+func (l *CountDownLatch) Count(ctx context.Context) (int64, error) {
+	count, found, err := l.client.backend.Get(ctx, l.key)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, ErrLatchNotSet
+	}
+	return strconv.ParseInt(count, 10, 64)
+}
+
+// Await blocks until the count of the latch reaches zero or ctx is done.
+// It returns ErrLatchNotSet if the latch is not set or expires, so the count must be set
+// before the waiters start, an expired latch can not tell whether the count reached zero.
+func (l *CountDownLatch) Await(ctx context.Context) error {
+	var notify <-chan struct{}
+	subscribed := false
+	for {
+		count, err := l.client.eval(ctx, luaLatchWait, []string{l.key}, l.expiration.Milliseconds()).Int64()
+		if err != nil {
+			return err
+		}
+
+		// -1 means the latch is not set or has expired.
+		if count == -1 {
+			return ErrLatchNotSet
+		}
+		if count <= 0 {
+			return nil
+		}
+
+		if !subscribed {
+			// subscribe only before waiting, so waiters finding the latch open hold no connection.
+			var closeSubscription func()
+			notify, closeSubscription, err = l.client.backend.Subscribe(ctx, l.channel())
+			if err != nil {
+				return err
+			}
+			defer closeSubscription()
+			subscribed = true
+
+			// the latch may open before the subscription, check it again at once.
+			continue
+		}
+
+		if err = l.client.waitNotified(ctx, notify, l.pollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// channel returns the channel notified when the latch is open.
+func (l *CountDownLatch) channel() string {
+	return l.key + ":open"
+}
@@ -0,0 +1,89 @@
+package redislock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestCountDownLatch(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	// init redislock client
+	client, err := NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+	key := "testLatch"
+	defer teardown(t, rdb, []string{key})
+
+	ctx := context.Background()
+	latch := client.NewCountDownLatch(key, 10*time.Second)
+
+	ok, err := latch.TrySetCount(ctx, 2)
+	if err != nil || !ok {
+		t.Fatalf("TrySetCount error:[%v], ok:[%v]", err, ok)
+	}
+
+	ok, err = latch.TrySetCount(ctx, 5)
+	if err != nil || ok {
+		t.Fatalf("TrySetCount of a set latch error:[%v], ok:[%v]", err, ok)
+	}
+
+	awaited := make(chan error, 1)
+	go func() {
+		awaited <- client.NewCountDownLatch(key, 10*time.Second).Await(ctx)
+	}()
+
+	t.Run("AwaitTimeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+
+		if err := latch.Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Await error is not equal,expected %v, got %v", context.DeadlineExceeded, err)
+		}
+	})
+
+	// test cases
+	cases := []struct {
+		Name  string
+		Count int64
+	}{
+		{"CountDownOnce", 1},
+		{"CountDownTwice", 0},
+		{"CountDownOpen", 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			if err := latch.CountDown(ctx); err != nil {
+				t.Fatalf("CountDown error:[%v]", err)
+			}
+
+			count, err := latch.Count(ctx)
+			if err != nil {
+				t.Fatalf("Count error:[%v]", err)
+			}
+
+			if count != c.Count {
+				t.Errorf("count is not equal,expected %v, got %v", c.Count, count)
+			}
+		})
+	}
+
+	select {
+	case err := <-awaited:
+		if err != nil {
+			t.Errorf("Await error:[%v]", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Await is not released after the count reaches zero")
+	}
+}
@@ -0,0 +1,90 @@
+package redislock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// LockerRetryInterval is the interval between two acquire attempts of Locker.Lock.
+const LockerRetryInterval = 100 * time.Millisecond
+
+// Locker is a sync.Locker holding the distributed lock of a fixed key with a background context.
+// sync.Locker can not return errors, so errors are passed to the error handler of the Locker,
+// if the error handler is nil, Locker panics with the error.
+type Locker struct {
+	client  *Client
+	key     string
+	options func() []LockOption // nil means the default options.
+	onError func(err error)
+
+	mu    sync.Mutex
+	mutex *Mutex
+}
+
+var _ sync.Locker = (*Locker)(nil)
+
+// NewLocker creates a new Locker of key, options returns the options of each acquire attempt,
+// so retry strategies and watch dogs, which are stateful, are not shared by the attempts.
+// nil options means the default options of WithLock.
+// onError handles the errors of Lock and Unlock, nil means panic.
+func (c *Client) NewLocker(key string, onError func(err error), options func() []LockOption) *Locker {
+	return &Locker{
+		client:  c,
+		key:     key,
+		options: options,
+		onError: onError,
+	}
+}
+
+// Lock blocks until the lock is acquired, attempts are repeated every LockerRetryInterval,
+// unexpected errors are passed to the error handler and the attempts go on.
+func (l *Locker) Lock() {
+	ctx := context.Background()
+	for {
+		var options []LockOption
+		if l.options != nil {
+			options = l.options()
+		}
+
+		mutex, err := l.client.acquire(ctx, l.key, options)
+		if err == nil {
+			l.mu.Lock()
+			l.mutex = mutex
+			l.mu.Unlock()
+			return
+		}
+
+		// the lock is held by others, context.DeadlineExceeded means the retries outlast the expiration.
+		if !IsMutexLockFailed(err) && !errors.Is(err, context.DeadlineExceeded) {
+			l.handleError(err)
+		}
+		_ = l.client.sleep(ctx, LockerRetryInterval)
+	}
+}
+
+// Unlock releases the lock, errors are passed to the error handler,
+// ErrMutexNotInitialized means the lock is not locked by Lock.
+func (l *Locker) Unlock() {
+	l.mu.Lock()
+	mutex := l.mutex
+	l.mutex = nil
+	l.mu.Unlock()
+
+	if mutex == nil {
+		l.handleError(ErrMutexNotInitialized)
+		return
+	}
+
+	if err := mutex.Unlock(context.Background()); err != nil {
+		l.handleError(err)
+	}
+}
+
+func (l *Locker) handleError(err error) {
+	if l.onError == nil {
+		panic(err)
+	}
+	l.onError(err)
+}
@@ -0,0 +1,86 @@
+package redislock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestLocker(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	// init redislock client
+	client, err := NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+	key := "testOne"
+	defer teardown(t, rdb, []string{key})
+
+	var errs []error
+	onError := func(err error) {
+		errs = append(errs, err)
+	}
+
+	lockerOne := client.NewLocker(key, onError, nil)
+	lockerTwo := client.NewLocker(key, onError, func() []LockOption {
+		return []LockOption{WithExpiration(10 * time.Second), WithRetryStrategy(NewAverageRetry(1, 10*time.Millisecond))}
+	})
+
+	lockerOne.Lock()
+
+	acquired := make(chan struct{})
+	go func() {
+		lockerTwo.Lock()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatalf("lockerTwo acquires the lock held by lockerOne")
+	case <-time.After(3 * LockerRetryInterval):
+	}
+
+	lockerOne.Unlock()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatalf("lockerTwo does not acquire the lock released by lockerOne")
+	}
+
+	lockerTwo.Unlock()
+	lockerTwo.Unlock()
+
+	if len(errs) != 1 || !IsMutexNotInitialized(errs[0]) {
+		t.Errorf("errors is not equal,expected %v, got %v", []error{ErrMutexNotInitialized}, errs)
+	}
+}
+
+func TestLocker_UnlockPanic(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	// init redislock client
+	client, err := NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+
+	defer func() {
+		r := recover()
+		if err, ok := r.(error); !ok || !IsMutexNotInitialized(err) {
+			t.Errorf("panic is not equal,expected %v, got %v", ErrMutexNotInitialized, r)
+		}
+	}()
+
+	client.NewLocker("testOne", nil, nil).Unlock()
+}
@@ -0,0 +1,27 @@
+package redislock
+
+import "encoding/hex"
+
+// Logger is the interface used by redislock to emit structured records,
+// args are alternating keys and values, *slog.Logger satisfies it.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Debug(string, ...interface{}) {}
+
+func (noopLogger) Info(string, ...interface{}) {}
+
+func (noopLogger) Warn(string, ...interface{}) {}
+
+func (noopLogger) Error(string, ...interface{}) {}
+
+// logToken returns the printable form of the lock value.
+func logToken(value string) string {
+	return hex.EncodeToString([]byte(value))
+}
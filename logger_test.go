@@ -0,0 +1,108 @@
+package redislock
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type record struct {
+	level string
+	msg   string
+	args  []interface{}
+}
+
+type recordLogger struct {
+	mu      sync.Mutex
+	records []record
+}
+
+func (r *recordLogger) log(level, msg string, args []interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, record{level: level, msg: msg, args: args})
+}
+
+func (r *recordLogger) Debug(msg string, args ...interface{}) { r.log("DEBUG", msg, args) }
+
+func (r *recordLogger) Info(msg string, args ...interface{}) { r.log("INFO", msg, args) }
+
+func (r *recordLogger) Warn(msg string, args ...interface{}) { r.log("WARN", msg, args) }
+
+func (r *recordLogger) Error(msg string, args ...interface{}) { r.log("ERROR", msg, args) }
+
+func TestClient_WithLogger(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	logger := &recordLogger{}
+	// init redislock client
+	client, err := NewClient(rdb, WithLogger(logger))
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+	key := "testOne"
+	defer teardown(t, rdb, []string{key})
+
+	ctx := context.Background()
+
+	mutex, err := client.TryLock(ctx, key, 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+
+	_, err = client.TryLockWithRetryStrategy(ctx, key, 10*time.Second, NewAverageRetry(1, 10*time.Millisecond))
+	if !IsMutexLockFailed(err) {
+		t.Fatalf("TryLockWithRetryStrategy error:[%v]", err)
+	}
+
+	if err = rdb.Del(ctx, key).Err(); err != nil {
+		t.Fatalf("Del error:[%v]", err)
+	}
+
+	if err = mutex.Unlock(ctx); !IsMutexNotHeld(err) {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+
+	// test cases
+	cases := []struct {
+		Name  string
+		Level string
+		Msg   string
+	}{
+		{"Retry", "DEBUG", "redislock: lock is held by others, retry later"},
+		{"LockFailed", "DEBUG", "redislock: lock is held by others"},
+		{"UnlockNotHeld", "WARN", "redislock: unlock lock not held"},
+	}
+
+	if len(logger.records) != len(cases) {
+		t.Fatalf("records is not equal,expected %v, got %v", len(cases), len(logger.records))
+	}
+
+	for i, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			actual := logger.records[i]
+			if actual.level != c.Level {
+				t.Errorf("level is not equal,expected %v, got %v", c.Level, actual.level)
+			}
+
+			if actual.msg != c.Msg {
+				t.Errorf("msg is not equal,expected %v, got %v", c.Msg, actual.msg)
+			}
+
+			if len(actual.args) < 2 || actual.args[0] != "key" || actual.args[1] != key {
+				t.Errorf("key is not equal,expected %v, got %v", key, actual.args)
+			}
+
+			if len(actual.args) < 4 || actual.args[2] != "token" {
+				t.Errorf("token is not logged, got %v", actual.args)
+			}
+		})
+	}
+}
@@ -1,8 +1,299 @@
 package redislock
 
-import "github.com/redis/go-redis/v9"
+import (
+	"crypto/sha1"
+	"encoding/hex"
+)
+
+// Script is a Lua script run by redislock through its Backend.
+type Script struct {
+	name   string // name in scripts, which names its function in the library.
+	source string
+	hash   string
+}
+
+func newScript(source string) *Script {
+	sum := sha1.Sum([]byte(source))
+	return &Script{source: source, hash: hex.EncodeToString(sum[:])}
+}
+
+// Source returns the source of the script.
+func (s *Script) Source() string {
+	return s.source
+}
+
+// Hash returns the sha1 hash of the script, which EVALSHA takes.
+func (s *Script) Hash() string {
+	return s.hash
+}
+
+var (
+	luaSetNX           = newScript(`return redis.call("set", KEYS[1], ARGV[1], "NX", "PX", ARGV[2])`)
+	luaLockWithFencing = newScript(`if redis.call("set", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then return redis.call("incr", KEYS[2]) else return 0 end`)
+	luaRefresh         = newScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`)
+	luaUnlock          = newScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`)
+	luaTransfer        = newScript(`if redis.call("get", KEYS[1]) == ARGV[1] then local ttl = redis.call("pttl", KEYS[1]) if ttl > 0 then redis.call("set", KEYS[1], ARGV[2], "PX", ttl) else redis.call("set", KEYS[1], ARGV[2]) end return ttl else return -2 end`)
+	luaPTTL            = newScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pttl", KEYS[1]) else return -2 end`)
+)
 
+// server time scripts, see WithServerTime. They return the PTTL of the lock and the TIME of redis,
+// which is called after the writes, so the scripts run before redis 5 too.
 var (
-	luaRefresh = redis.NewScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`)
-	luaUnlock  = redis.NewScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`)
+	luaLockServerTime = newScript(`
+if not redis.call("set", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then return false end
+local token = 0
+if KEYS[2] then token = redis.call("incr", KEYS[2]) end
+local now = redis.call("time")
+return {token, redis.call("pttl", KEYS[1]), now[1], now[2]}`)
+	luaRefreshServerTime = newScript(`
+if redis.call("get", KEYS[1]) ~= ARGV[1] then return false end
+redis.call("pexpire", KEYS[1], ARGV[2])
+local now = redis.call("time")
+return {redis.call("pttl", KEYS[1]), now[1], now[2]}`)
 )
+
+// read-write lock scripts, the lock is a hash in KEYS[1]: "mode" is "read" or "write",
+// "w" is the writer and "u" is the reader waiting to upgrade, which blocks new readers.
+// The readers are a sorted set in KEYS[2] scored by their deadlines in the time of redis,
+// so a crashed reader expires on its own even while other readers keep the lock.
+const rwPrune = `
+if redis.replicate_commands then redis.replicate_commands() end
+local time = redis.call("time")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+redis.call("zremrangebyscore", KEYS[2], "-inf", now)
+local upgrader = redis.call("hget", KEYS[1], "u")
+if upgrader and not redis.call("zscore", KEYS[2], upgrader) then
+	redis.call("hdel", KEYS[1], "u")
+	upgrader = false
+end
+if redis.call("hget", KEYS[1], "mode") == "read" and redis.call("zcard", KEYS[2]) == 0 then
+	redis.call("del", KEYS[1])
+end
+local function keep(expiration)
+	for _, key in ipairs(KEYS) do
+		if redis.call("pttl", key) < tonumber(expiration) then
+			redis.call("pexpire", key, expiration)
+		end
+	end
+end
+`
+
+var (
+	luaReadLock = newScript(rwPrune + `
+local mode = redis.call("hget", KEYS[1], "mode")
+if mode == false or (mode == "read" and upgrader == false) then
+	redis.call("hset", KEYS[1], "mode", "read")
+	redis.call("zadd", KEYS[2], now + tonumber(ARGV[2]), ARGV[1])
+	keep(ARGV[2])
+	return 1
+end
+return 0`)
+	luaWriteLock = newScript(rwPrune + `
+if redis.call("exists", KEYS[1]) == 0 then
+	redis.call("hset", KEYS[1], "mode", "write", "w", ARGV[1])
+	redis.call("pexpire", KEYS[1], ARGV[2])
+	return 1
+end
+return 0`)
+	luaReadUnlock = newScript(rwPrune + `
+if redis.call("zrem", KEYS[2], ARGV[1]) == 0 then
+	return 0
+end
+if upgrader == ARGV[1] then
+	redis.call("hdel", KEYS[1], "u")
+end
+if redis.call("zcard", KEYS[2]) == 0 then
+	redis.call("del", KEYS[1])
+end
+return 1`)
+	luaWriteUnlock = newScript(`
+if redis.call("hget", KEYS[1], "w") == ARGV[1] then
+	return redis.call("del", KEYS[1], KEYS[2])
+end
+return 0`)
+	luaRWRefresh = newScript(rwPrune + `
+if redis.call("hget", KEYS[1], "w") == ARGV[1] then
+	keep(ARGV[2])
+	return 1
+end
+if redis.call("zscore", KEYS[2], ARGV[1]) then
+	redis.call("zadd", KEYS[2], now + tonumber(ARGV[2]), ARGV[1])
+	keep(ARGV[2])
+	return 1
+end
+return 0`)
+	// luaUpgrade returns -1 if the reader does not hold the lock, -2 if another reader is upgrading,
+	// 0 if other readers have to drain and 1 if the reader becomes the writer.
+	luaUpgrade = newScript(rwPrune + `
+if not redis.call("zscore", KEYS[2], ARGV[1]) then
+	return -1
+end
+if upgrader ~= false and upgrader ~= ARGV[1] then
+	return -2
+end
+if redis.call("zcard", KEYS[2]) > 1 then
+	redis.call("hset", KEYS[1], "u", ARGV[1])
+	return 0
+end
+redis.call("del", KEYS[1], KEYS[2])
+redis.call("hset", KEYS[1], "mode", "write", "w", ARGV[1])
+redis.call("pexpire", KEYS[1], ARGV[2])
+return 1`)
+	luaCancelUpgrade = newScript(`
+if redis.call("hget", KEYS[1], "u") == ARGV[1] then
+	return redis.call("hdel", KEYS[1], "u")
+end
+return 0`)
+	luaDowngrade = newScript(rwPrune + `
+if redis.call("hget", KEYS[1], "w") == ARGV[1] then
+	redis.call("hdel", KEYS[1], "w")
+	redis.call("hset", KEYS[1], "mode", "read")
+	redis.call("zadd", KEYS[2], now + tonumber(ARGV[2]), ARGV[1])
+	keep(ARGV[2])
+	return 1
+end
+return 0`)
+)
+
+// result scripts of SingleFlight and Idempotency.
+var (
+	// luaPublishHeldResult stores ARGV[2] in KEYS[2] for ARGV[3] milliseconds and publishes it to ARGV[4]
+	// only if the lock KEYS[1] is still held by ARGV[1], it returns 0 otherwise.
+	luaPublishHeldResult = newScript(`
+if redis.call("get", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+redis.call("set", KEYS[2], ARGV[2], "PX", ARGV[3])
+redis.call("publish", ARGV[4], KEYS[2])
+return 1`)
+)
+
+// count down latch scripts, the key of the latch is its count, the latch is open when the count is 0,
+// which is kept as a tombstone, and ARGV[2] is published when the count reaches zero.
+// A missing key means the latch is not set or has expired.
+var (
+	luaLatchSetCount = newScript(`
+local count = redis.call("get", KEYS[1])
+if count and tonumber(count) > 0 then
+	return 0
+end
+redis.call("set", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1`)
+	// luaLatchCountDown returns the count left, -1 if the latch is not set or has expired.
+	luaLatchCountDown = newScript(`
+local count = redis.call("get", KEYS[1])
+if not count then
+	return -1
+end
+if tonumber(count) <= 0 then
+	return 0
+end
+count = redis.call("decr", KEYS[1])
+if count <= 0 then
+	redis.call("set", KEYS[1], 0, "PX", ARGV[1])
+	redis.call("publish", ARGV[2], KEYS[1])
+	return 0
+end
+redis.call("pexpire", KEYS[1], ARGV[1])
+return count`)
+	// luaLatchWait refreshes the latch for ARGV[1] milliseconds, so it does not expire while waiters wait,
+	// and returns the count, -1 if the latch is not set or has expired.
+	luaLatchWait = newScript(`
+local count = redis.call("get", KEYS[1])
+if not count then
+	return -1
+end
+redis.call("pexpire", KEYS[1], ARGV[1])
+return tonumber(count)`)
+)
+
+// barrier scripts, the barrier is a hash: "arrived" is the count of waiting parties
+// and "generation" increases each time all parties arrive, which is published to ARGV[3].
+var (
+	// luaBarrierArrive returns -1 if all parties arrive, otherwise the generation to wait for.
+	luaBarrierArrive = newScript(`
+local generation = tonumber(redis.call("hget", KEYS[1], "generation") or "0")
+local arrived = redis.call("hincrby", KEYS[1], "arrived", 1)
+redis.call("pexpire", KEYS[1], ARGV[2])
+if arrived >= tonumber(ARGV[1]) then
+	redis.call("hset", KEYS[1], "arrived", 0, "generation", generation + 1)
+	redis.call("publish", ARGV[3], generation + 1)
+	return -1
+end
+return generation`)
+	// luaBarrierWait refreshes the barrier for ARGV[1] milliseconds, so it does not expire while parties wait,
+	// and returns the generation, -1 if the barrier has expired.
+	luaBarrierWait = newScript(`
+if redis.call("exists", KEYS[1]) == 0 then
+	return -1
+end
+redis.call("pexpire", KEYS[1], ARGV[1])
+return tonumber(redis.call("hget", KEYS[1], "generation") or "0")`)
+	luaBarrierLeave = newScript(`
+if redis.call("exists", KEYS[1]) == 1 and tonumber(redis.call("hget", KEYS[1], "generation") or "0") == tonumber(ARGV[1]) then
+	redis.call("hincrby", KEYS[1], "arrived", -1)
+	return 1
+end
+return 0`)
+)
+
+// partition member scripts, the members are a sorted set scored by their expiration time in milliseconds.
+var (
+	// luaMemberHeartbeat renews member ARGV[1] for ARGV[2] milliseconds in the time of redis,
+	// so the clocks of the members do not matter, and returns the alive members.
+	luaMemberHeartbeat = newScript(`
+if redis.replicate_commands then redis.replicate_commands() end
+local time = redis.call("time")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+redis.call("zadd", KEYS[1], now + tonumber(ARGV[2]), ARGV[1])
+redis.call("zremrangebyscore", KEYS[1], "-inf", "(" .. now)
+redis.call("pexpire", KEYS[1], ARGV[2])
+return redis.call("zrange", KEYS[1], 0, -1)`)
+	luaMemberLeave = newScript(`return redis.call("zrem", KEYS[1], ARGV[1])`)
+)
+
+// scripts are the scripts of redislock by name.
+var scripts = map[string]*Script{
+	"lock":                luaLockWithFencing,
+	"refresh":             luaRefresh,
+	"unlock":              luaUnlock,
+	"transfer":            luaTransfer,
+	"pttl":                luaPTTL,
+	"set_nx":              luaSetNX,
+	"lock_server_time":    luaLockServerTime,
+	"refresh_server_time": luaRefreshServerTime,
+	"read_lock":           luaReadLock,
+	"write_lock":          luaWriteLock,
+	"read_unlock":         luaReadUnlock,
+	"write_unlock":        luaWriteUnlock,
+	"rw_refresh":          luaRWRefresh,
+	"upgrade":             luaUpgrade,
+	"cancel_upgrade":      luaCancelUpgrade,
+	"downgrade":           luaDowngrade,
+	"publish_held_result": luaPublishHeldResult,
+	"latch_set_count":     luaLatchSetCount,
+	"latch_count_down":    luaLatchCountDown,
+	"latch_wait":          luaLatchWait,
+	"barrier_arrive":      luaBarrierArrive,
+	"barrier_wait":        luaBarrierWait,
+	"barrier_leave":       luaBarrierLeave,
+	"member_heartbeat":    luaMemberHeartbeat,
+	"member_leave":        luaMemberLeave,
+}
+
+func init() {
+	for name, script := range scripts {
+		script.name = name
+	}
+}
+
+// ScriptName returns the name of the redislock script whose sha1 hash is sha1,
+// such as "lock", "refresh" or "unlock", it returns "" for other scripts.
+func ScriptName(sha1 string) string {
+	for name, script := range scripts {
+		if script.Hash() == sha1 {
+			return name
+		}
+	}
+	return ""
+}
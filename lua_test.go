@@ -0,0 +1,46 @@
+package redislock
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestScriptName(t *testing.T) {
+	// test cases
+	cases := []struct {
+		Name     string
+		Sha1     string
+		Expected string
+	}{
+		{"Lock", luaLockWithFencing.Hash(), "lock"},
+		{"Refresh", luaRefresh.Hash(), "refresh"},
+		{"Unlock", luaUnlock.Hash(), "unlock"},
+		{"Unknown", redis.NewScript(`return 1`).Hash(), ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			if actual := ScriptName(c.Sha1); actual != c.Expected {
+				t.Fatalf("ScriptName is not equal,expected %v, got %v", c.Expected, actual)
+			}
+		})
+	}
+}
+
+func TestFunctionName(t *testing.T) {
+	if actual := functionName("lock"); actual != "redislock_v9_lock" {
+		t.Fatalf("functionName is not equal,expected %v, got %v", "redislock_v9_lock", actual)
+	}
+}
+
+// TestLibraryVersion fails when a script changes, increase LibraryVersion and update the expected hash.
+func TestLibraryVersion(t *testing.T) {
+	expected := "c1bb89fea1d2827fafec43540f6db91b58e41583"
+	sum := sha1.Sum([]byte(library))
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		t.Fatalf("library hash is not equal,expected %v, got %v, LibraryVersion %d may need to increase", expected, actual, LibraryVersion)
+	}
+}
@@ -2,6 +2,7 @@ package redislock
 
 import (
 	"context"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -10,33 +11,53 @@ import (
 
 // Mutex is a distributed mutex implementation based on redis.
 type Mutex struct {
-	client        *Client
-	key           string
-	value         string
-	expiration    time.Duration
-	retryStrategy RetryStrategy
-	watchDog      *WatchDog
+	client         *Client
+	key            string
+	value          string
+	expiration     time.Duration
+	retryStrategy  RetryStrategy
+	watchDog       *WatchDog
+	acquiredAt     time.Time     // time of the successful acquire attempt.
+	fencingToken   int64         // 0 if fencing is not enabled.
+	deadline       time.Time     // last known deadline of the lock.
+	serverDeadline time.Time     // last known deadline of the lock in the time of redis, see WithServerTime.
+	ttl            time.Duration // PTTL observed by the last acquire or refresh, see WithServerTime.
+	lost           chan struct{} // closed when the lock is lost.
+	lostOnce       sync.Once
+	extended       chan struct{}   // closed and replaced when Extend changes the expiration.
+	watchDogCtx    context.Context // context the watch dog is started with.
+	mu             sync.RWMutex    // guards expiration, deadline, serverDeadline, ttl, extended and watchDogCtx.
+	refreshMu      sync.Mutex      // serializes refreshes and Extend, so a refresh in flight does not undo an extension.
 }
 
 type mutexOption struct {
 	retryStrategy RetryStrategy
 	watchDog      *WatchDog
+	token         string // value of the lock, generated if empty.
 }
 
 // Unlock releases the lock.
 func (m *Mutex) Unlock(ctx context.Context) error {
-	defer func() {
-		// stop watch dog
-		if m.watchDog != nil {
-			m.stopWatchDog()
-		}
-	}()
-
 	if m == nil {
 		return ErrMutexNotInitialized
 	}
 
-	status, err := luaUnlock.Run(ctx, m.client.redisClient, []string{m.key}, m.value).Int()
+	// stop watch dog first, so a refresh in flight does not take the unlock for a lost lock.
+	if m.watchDog != nil {
+		m.stopWatchDog()
+	}
+
+	err := m.client.trace(ctx, OperationUnlock, m.key, m.unlock)
+	if IsMutexNotHeld(err) {
+		m.client.logger.Warn("redislock: unlock lock not held", "key", m.key, "token", logToken(m.value))
+	} else if err != nil {
+		m.client.logger.Error("redislock: unlock failed", "key", m.key, "token", logToken(m.value), "error", err)
+	}
+	return err
+}
+
+func (m *Mutex) unlock(ctx context.Context) error {
+	status, err := m.client.eval(ctx, luaUnlock, []string{m.key}, m.value).Int()
 	if err == redis.Nil {
 		return ErrMutexNotHeld
 	} else if err != nil {
@@ -49,45 +70,213 @@ func (m *Mutex) Unlock(ctx context.Context) error {
 	return nil
 }
 
-// Refresh resets the lock's expiration.
+// Refresh resets the lock's expiration,
+// returns ErrMutexNotHeld if the lock is no longer held.
 func (m *Mutex) Refresh(ctx context.Context) error {
-	return m.refresh(ctx)
+	if m == nil {
+		return ErrMutexNotHeld
+	}
+
+	return m.client.trace(ctx, OperationRefresh, m.key, m.refresh)
 }
 
 func (m *Mutex) refresh(ctx context.Context) error {
-	if m == nil {
-		return ErrMutexNotHeld
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+	return m.pexpire(ctx, m.getExpiration())
+}
+
+// pexpire sets the expiration of the lock in redis if the lock is still held.
+func (m *Mutex) pexpire(ctx context.Context, expiration time.Duration) error {
+	if m.client.serverTime {
+		return m.pexpireServerTime(ctx, expiration)
 	}
 
-	status, err := luaRefresh.Run(ctx, m.client.redisClient, []string{m.key}, m.value, m.expiration.Milliseconds()).Int()
+	now := m.client.clock.Now()
+	cmd, waitErr := m.client.evalWait(ctx, m.client.waitRefresh, luaRefresh, []string{m.key}, m.value, expiration.Milliseconds())
+	status, err := cmd.Int()
 	if err != nil {
 		return err
 	}
 
-	if status == 1 {
-		return nil
+	if status != 1 {
+		return ErrMutexNotHeld
+	}
+	if waitErr != nil {
+		return waitErr
 	}
+
+	m.mu.Lock()
+	m.deadline = now.Add(expiration)
+	m.mu.Unlock()
 	return nil
 }
 
+// Key returns the key of the lock.
+func (m *Mutex) Key() string {
+	return m.key
+}
+
+// Token returns the value of the lock, which is unique to the holder.
+func (m *Mutex) Token() string {
+	return m.value
+}
+
+// AcquiredAt returns the time of the successful acquire attempt.
+func (m *Mutex) AcquiredAt() time.Time {
+	return m.acquiredAt
+}
+
+// Expiration returns the configured expiration of the lock.
+func (m *Mutex) Expiration() time.Duration {
+	return m.getExpiration()
+}
+
+func (m *Mutex) getExpiration() time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.expiration
+}
+
+// FencingToken returns the fencing token of the lock, 0 if fencing is not enabled.
+func (m *Mutex) FencingToken() int64 {
+	return m.fencingToken
+}
+
+// Deadline returns the last known deadline of the lock,
+// measured before the latest acquire, refresh or extend request is sent.
+// With WithServerTime it is the time the request is sent plus the PTTL observed in redis.
+func (m *Mutex) Deadline() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.deadline
+}
+
+// ServerDeadline returns the last known deadline of the lock in the time of redis,
+// the TIME of redis plus the PTTL of the lock observed by the latest acquire, refresh or extend request.
+// It is zero unless the client uses WithServerTime.
+func (m *Mutex) ServerDeadline() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.serverDeadline
+}
+
+// Extend sets the expiration of the lock to expiration if the lock is still held,
+// following refreshes and the watch dog use the new expiration, the watch dog is rescheduled at once.
+// It waits for a refresh in flight, so the refresh does not restore the old expiration.
+// It returns the new deadline of the lock, measured before the request is sent.
+// It returns ErrInvalidExpiration if expiration is not positive.
+func (m *Mutex) Extend(ctx context.Context, expiration time.Duration) (time.Time, error) {
+	if m == nil {
+		return time.Time{}, ErrMutexNotHeld
+	}
+
+	if expiration <= 0 {
+		return time.Time{}, ErrInvalidExpiration
+	}
+
+	err := m.client.trace(ctx, OperationExtend, m.key, func(ctx context.Context) error {
+		m.refreshMu.Lock()
+		defer m.refreshMu.Unlock()
+
+		if err := m.pexpire(ctx, expiration); err != nil {
+			return err
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.expiration = expiration
+		close(m.extended)
+		m.extended = make(chan struct{})
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return m.Deadline(), nil
+}
+
+// extendedChan returns the channel closed by the next Extend.
+func (m *Mutex) extendedChan() <-chan struct{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.extended
+}
+
+// TTL returns the remaining time to live of the lock in redis,
+// returns ErrMutexNotHeld if the lock is no longer held.
+func (m *Mutex) TTL(ctx context.Context) (time.Duration, error) {
+	if m == nil {
+		return 0, ErrMutexNotHeld
+	}
+
+	ttl, err := m.client.eval(ctx, luaPTTL, []string{m.key}, m.value).Int64()
+	if err != nil {
+		return 0, err
+	}
+
+	// -2 means the lock is not held, -1 means the lock has no expiration.
+	if ttl == -2 {
+		return 0, ErrMutexNotHeld
+	}
+	if ttl == -1 {
+		return -1, nil
+	}
+	return time.Duration(ttl) * time.Millisecond, nil
+}
+
+// IsHeld returns true if the lock is still held.
+func (m *Mutex) IsHeld(ctx context.Context) (bool, error) {
+	_, err := m.TTL(ctx)
+	if IsMutexNotHeld(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (m *Mutex) runWatchDog(ctx context.Context) {
 	for !atomic.CompareAndSwapUint32(&m.watchDog.isStart, 0, 1) {
 	}
 
+	m.mu.Lock()
+	m.watchDogCtx = ctx
+	m.mu.Unlock()
+
 	ctx, m.watchDog.cancelFunc = context.WithCancel(ctx)
+	done := make(chan struct{})
+	m.watchDog.done = done
 	go func() {
-		ticker := time.NewTicker(m.watchDog.expiration / 3)
-		defer ticker.Stop()
+		defer close(done)
+		defer atomic.StoreUint32(&m.watchDog.isStart, 0)
 
-		for range ticker.C {
+		for {
+			// the expiration may be changed by Extend, then the interval is measured from the extension.
+			extended := m.extendedChan()
+			timer, stop := m.client.clock.NewTimer(m.renewInterval())
 			select {
 			case <-ctx.Done():
-				atomic.StoreUint32(&m.watchDog.isStart, 0)
+				stop()
 				return
-			default:
+			case <-extended:
+				stop()
+				continue
+			case <-timer:
 			}
 
-			if err := m.refresh(ctx); err != nil {
+			if err := m.client.trace(ctx, OperationWatchDogRefresh, m.key, m.refresh); err != nil {
+				// the watch dog is stopped, the lock may be released or transferred meanwhile.
+				if ctx.Err() != nil {
+					return
+				}
+
+				if IsMutexNotHeld(err) {
+					m.client.logger.Error("redislock: lock lost", "key", m.key, "token", logToken(m.value))
+				} else {
+					m.client.logger.Warn("redislock: watch dog refresh failed", "key", m.key, "token", logToken(m.value), "error", err)
+				}
+				m.markLost()
 				m.watchDog.cancelFunc()
 				return
 			}
@@ -95,6 +284,45 @@ func (m *Mutex) runWatchDog(ctx context.Context) {
 	}()
 }
 
+// renewInterval returns the time until the next refresh of the watch dog, a third of the expiration,
+// or of the PTTL observed by the latest acquire or refresh if the client uses WithServerTime.
+func (m *Mutex) renewInterval() time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.ttl > 0 {
+		return m.ttl / 3
+	}
+	return m.expiration / 3
+}
+
+// Lost returns a channel that is closed when the watch dog fails to keep the lock,
+// it is never closed for a mutex without watch dog.
+func (m *Mutex) Lost() <-chan struct{} {
+	return m.lost
+}
+
+func (m *Mutex) markLost() {
+	m.lostOnce.Do(func() {
+		close(m.lost)
+	})
+}
+
+// restartWatchDog runs the stopped watch dog again with the context it was started with,
+// after the stopped goroutine has returned from its refresh in flight.
+func (m *Mutex) restartWatchDog() {
+	if m.watchDog.done != nil {
+		<-m.watchDog.done
+	}
+
+	m.mu.RLock()
+	ctx := m.watchDogCtx
+	m.mu.RUnlock()
+
+	if ctx != nil && ctx.Err() == nil {
+		m.runWatchDog(ctx)
+	}
+}
+
 func (m *Mutex) stopWatchDog() {
 	if m.watchDog.cancelFunc != nil {
 		m.watchDog.cancelFunc()
@@ -108,6 +336,8 @@ func newMutex(client *Client, key, value string, expiration time.Duration, strat
 		value:         value,
 		expiration:    expiration,
 		retryStrategy: strategy,
+		lost:          make(chan struct{}),
+		extended:      make(chan struct{}),
 	}
 }
 
@@ -0,0 +1,168 @@
+package redislock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMutex_Introspection(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	// init redislock client
+	client, err := NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+	keyOne := "testOne"
+	keyTwo := "testTwo"
+	defer teardown(t, rdb, []string{keyOne, keyTwo})
+
+	ctx := context.Background()
+
+	before := time.Now()
+	actualOne, err := client.TryLock(ctx, keyOne, 10*time.Second)
+	if err != nil {
+		t.Fatalf("actualOne TryLock error:[%v]", err)
+	}
+
+	actualTwo, err := client.TryLock(ctx, keyTwo, 10*time.Second)
+	if err != nil {
+		t.Fatalf("actualTwo TryLock error:[%v]", err)
+	}
+
+	// the lock of keyTwo is taken over by others.
+	if err = rdb.Set(ctx, keyTwo, "others", 10*time.Second).Err(); err != nil {
+		t.Fatalf("Set error:[%v]", err)
+	}
+
+	// test cases
+	cases := []struct {
+		Name   string
+		Actual *Mutex
+		Held   bool
+	}{
+		{"MutexHeld", actualOne, true},
+		{"MutexNotHeld", actualTwo, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			held, err := c.Actual.IsHeld(ctx)
+			if err != nil {
+				t.Fatalf("IsHeld error:[%v]", err)
+			}
+
+			if held != c.Held {
+				t.Errorf("held is not equal,expected %v, got %v", c.Held, held)
+			}
+
+			ttl, err := c.Actual.TTL(ctx)
+			if c.Held {
+				if err != nil {
+					t.Fatalf("TTL error:[%v]", err)
+				}
+				if ttl <= 0 || ttl > c.Actual.Expiration() {
+					t.Errorf("ttl is not in (0, %v], got %v", c.Actual.Expiration(), ttl)
+				}
+			} else if !IsMutexNotHeld(err) {
+				t.Errorf("TTL error is not equal,expected %v, got %v", ErrMutexNotHeld, err)
+			}
+
+			if c.Actual.Expiration() != 10*time.Second {
+				t.Errorf("expiration is not equal,expected %v, got %v", 10*time.Second, c.Actual.Expiration())
+			}
+
+			if c.Actual.AcquiredAt().Before(before) || c.Actual.AcquiredAt().After(time.Now()) {
+				t.Errorf("acquiredAt is not between %v and now, got %v", before, c.Actual.AcquiredAt())
+			}
+		})
+	}
+
+	if actualOne.Key() != keyOne {
+		t.Errorf("key is not equal,expected %v, got %v", keyOne, actualOne.Key())
+	}
+
+	token, err := rdb.Get(ctx, keyOne).Result()
+	if err != nil {
+		t.Fatalf("Get error:[%v]", err)
+	}
+
+	if actualOne.Token() != token {
+		t.Errorf("token is not equal,expected %v, got %v", token, actualOne.Token())
+	}
+}
+
+func TestMutex_Extend(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	// init redislock client
+	client, err := NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+	keyOne := "testOne"
+	keyTwo := "testTwo"
+	defer teardown(t, rdb, []string{keyOne, keyTwo})
+
+	ctx := context.Background()
+
+	actualOne, err := client.TryLock(ctx, keyOne, 10*time.Second)
+	if err != nil {
+		t.Fatalf("actualOne TryLock error:[%v]", err)
+	}
+
+	actualTwo, err := client.TryLock(ctx, keyTwo, 10*time.Second)
+	if err != nil {
+		t.Fatalf("actualTwo TryLock error:[%v]", err)
+	}
+
+	if err = rdb.Del(ctx, keyTwo).Err(); err != nil {
+		t.Fatalf("Del error:[%v]", err)
+	}
+
+	t.Run("ExtendHeld", func(t *testing.T) {
+		before := time.Now()
+		deadline, err := actualOne.Extend(ctx, time.Minute)
+		if err != nil {
+			t.Fatalf("Extend error:[%v]", err)
+		}
+
+		if deadline.Before(before.Add(time.Minute)) || deadline.After(time.Now().Add(time.Minute)) {
+			t.Errorf("deadline is not about a minute later, got %v", deadline)
+		}
+
+		if actualOne.Expiration() != time.Minute {
+			t.Errorf("expiration is not equal,expected %v, got %v", time.Minute, actualOne.Expiration())
+		}
+
+		ttl, err := actualOne.TTL(ctx)
+		if err != nil {
+			t.Fatalf("TTL error:[%v]", err)
+		}
+
+		if ttl <= 10*time.Second {
+			t.Errorf("ttl is not extended, got %v", ttl)
+		}
+	})
+
+	t.Run("ExtendNotHeld", func(t *testing.T) {
+		if _, err := actualTwo.Extend(ctx, time.Minute); !IsMutexNotHeld(err) {
+			t.Errorf("Extend error is not equal,expected %v, got %v", ErrMutexNotHeld, err)
+		}
+
+		if actualTwo.Expiration() != 10*time.Second {
+			t.Errorf("expiration is not equal,expected %v, got %v", 10*time.Second, actualTwo.Expiration())
+		}
+	})
+}
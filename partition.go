@@ -0,0 +1,268 @@
+package redislock
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultRebalanceInterval is the default interval a PartitionManager rebalances its partitions.
+const DefaultRebalanceInterval = time.Second
+
+// PartitionManager owns a fair share of the partitions of a group among the alive members,
+// each partition is a lock kept by a watch dog, members join and leave by heartbeats,
+// extra partitions are given up when new members join.
+type PartitionManager struct {
+	client            *Client
+	group             string
+	member            string
+	partitions        int
+	expiration        time.Duration                  // expiration of partition locks and member heartbeats.
+	rebalanceInterval time.Duration                  // interval of heartbeats and rebalancing.
+	onChange          func(acquired, released []int) // called when the owned partitions change.
+	onError           func(partition int, err error) // called when redis fails, partition is -1 for heartbeats.
+
+	mu    sync.Mutex
+	owned map[int]*Mutex
+}
+
+// NewPartitionManager creates a new PartitionManager of member for partitions of group,
+// member must be unique in the group, it returns ErrInvalidPartitions if partitions is not positive.
+func (c *Client) NewPartitionManager(group, member string, partitions int, options ...PartitionOption) (*PartitionManager, error) {
+	if partitions <= 0 {
+		return nil, ErrInvalidPartitions
+	}
+
+	p := &PartitionManager{
+		client:            c,
+		group:             group,
+		member:            member,
+		partitions:        partitions,
+		expiration:        DefaultExpiration,
+		rebalanceInterval: DefaultRebalanceInterval,
+		owned:             make(map[int]*Mutex),
+	}
+
+	for _, option := range options {
+		option(p)
+	}
+
+	return p, nil
+}
+
+type PartitionOption func(manager *PartitionManager)
+
+// WithPartitionExpiration sets the expiration of partition locks and member heartbeats,
+// it should be longer than the rebalance interval.
+func WithPartitionExpiration(expiration time.Duration) PartitionOption {
+	return func(manager *PartitionManager) {
+		manager.expiration = expiration
+	}
+}
+
+// WithRebalanceInterval sets the interval of heartbeats and rebalancing.
+func WithRebalanceInterval(rebalanceInterval time.Duration) PartitionOption {
+	return func(manager *PartitionManager) {
+		manager.rebalanceInterval = rebalanceInterval
+	}
+}
+
+// WithOnChange sets the callback called when the owned partitions change,
+// released includes the partitions lost because their watch dogs fail.
+func WithOnChange(onChange func(acquired, released []int)) PartitionOption {
+	return func(manager *PartitionManager) {
+		manager.onChange = onChange
+	}
+}
+
+// WithOnPartitionError sets the callback called when redis fails,
+// partition is -1 for member heartbeats.
+func WithOnPartitionError(onError func(partition int, err error)) PartitionOption {
+	return func(manager *PartitionManager) {
+		manager.onError = onError
+	}
+}
+
+// Run rebalances the partitions until ctx is done, then it releases them and leaves the group.
+func (p *PartitionManager) Run(ctx context.Context) error {
+	for {
+		p.rebalance(ctx)
+
+		if err := p.client.sleep(ctx, p.rebalanceInterval); err != nil {
+			p.leave()
+			return err
+		}
+	}
+}
+
+// Owned returns the partitions owned by the member in ascending order.
+func (p *PartitionManager) Owned() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	owned := make([]int, 0, len(p.owned))
+	for partition := range p.owned {
+		owned = append(owned, partition)
+	}
+	sort.Ints(owned)
+	return owned
+}
+
+// rebalance renews the heartbeat of the member, drops lost partitions,
+// and releases or acquires partitions to reach the fair share.
+func (p *PartitionManager) rebalance(ctx context.Context) {
+	members, err := p.client.eval(ctx, luaMemberHeartbeat, []string{p.membersKey()},
+		p.member, p.expiration.Milliseconds()).StringSlice()
+	if err != nil {
+		p.handleError(-1, err)
+		return
+	}
+	share := p.share(members)
+
+	var acquired, released []int
+	p.mu.Lock()
+	for partition, mutex := range p.owned {
+		select {
+		case <-mutex.Lost():
+			delete(p.owned, partition)
+			released = append(released, partition)
+		default:
+		}
+	}
+	p.mu.Unlock()
+
+	owned := p.Owned()
+	// give up the extra partitions, from the highest.
+	for i := len(owned) - 1; i >= share; i-- {
+		if p.release(ctx, owned[i]) {
+			released = append(released, owned[i])
+		}
+	}
+
+	// start from an offset of the member, so members do not contend for the same partitions.
+	offset := int(memberHash(p.member) % uint32(p.partitions))
+	for i := 0; i < p.partitions && len(owned)+len(acquired) < share; i++ {
+		partition := (offset + i) % p.partitions
+		if p.isOwned(partition) {
+			continue
+		}
+
+		mutex, err := p.client.TryLockWithWatchDog(ctx, p.partitionKey(partition), NewWatchDog(p.expiration))
+		if IsMutexLockFailed(err) {
+			continue
+		} else if err != nil {
+			p.handleError(partition, err)
+			continue
+		}
+
+		p.mu.Lock()
+		p.owned[partition] = mutex
+		p.mu.Unlock()
+		acquired = append(acquired, partition)
+	}
+
+	if p.onChange != nil && (len(acquired) != 0 || len(released) != 0) {
+		sort.Ints(acquired)
+		sort.Ints(released)
+		p.onChange(acquired, released)
+	}
+}
+
+// share returns the fair share of the member among the alive members: each member owns
+// partitions/len(members) partitions, and the first partitions%len(members) members ordered by hash
+// own one more, so the shares add up to partitions.
+func (p *PartitionManager) share(members []string) int {
+	sort.Slice(members, func(i, j int) bool {
+		hi, hj := memberHash(members[i]), memberHash(members[j])
+		if hi != hj {
+			return hi < hj
+		}
+		return members[i] < members[j]
+	})
+
+	// the heartbeat adds the member, so it is always found.
+	if len(members) == 0 {
+		members = []string{p.member}
+	}
+	rank := 0
+	for rank < len(members) && members[rank] != p.member {
+		rank++
+	}
+
+	share := p.partitions / len(members)
+	if rank < p.partitions%len(members) {
+		share++
+	}
+	return share
+}
+
+// release releases partition, it returns true if the partition is no longer owned.
+func (p *PartitionManager) release(ctx context.Context, partition int) bool {
+	p.mu.Lock()
+	mutex := p.owned[partition]
+	delete(p.owned, partition)
+	p.mu.Unlock()
+
+	if mutex == nil {
+		return false
+	}
+
+	if err := mutex.Unlock(ctx); err != nil && !IsMutexNotHeld(err) {
+		p.handleError(partition, err)
+	}
+	return true
+}
+
+// leave releases all partitions and leaves the group.
+func (p *PartitionManager) leave() {
+	// leave even if ctx of Run is done, bounded so a hung connection does not block.
+	ctx, cancel := context.WithTimeout(context.Background(), ReleaseTimeout)
+	defer cancel()
+
+	var released []int
+	for _, partition := range p.Owned() {
+		if p.release(ctx, partition) {
+			released = append(released, partition)
+		}
+	}
+
+	if err := p.client.eval(ctx, luaMemberLeave, []string{p.membersKey()}, p.member).Err(); err != nil {
+		p.handleError(-1, err)
+	}
+
+	if p.onChange != nil && len(released) != 0 {
+		p.onChange(nil, released)
+	}
+}
+
+func (p *PartitionManager) isOwned(partition int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.owned[partition]
+	return ok
+}
+
+func (p *PartitionManager) handleError(partition int, err error) {
+	if p.onError != nil {
+		p.onError(partition, err)
+	}
+}
+
+// membersKey returns the key of the alive members of the group.
+func (p *PartitionManager) membersKey() string {
+	return p.group + ":members"
+}
+
+// partitionKey returns the key of the lock of partition.
+func (p *PartitionManager) partitionKey(partition int) string {
+	return p.group + ":partition:" + strconv.Itoa(partition)
+}
+
+func memberHash(member string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(member))
+	return h.Sum32()
+}
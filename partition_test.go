@@ -0,0 +1,169 @@
+package redislock
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestPartitionManager_Run(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	// init redislock client
+	client, err := NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+	group := "testGroup"
+
+	var mu sync.Mutex
+	changes := make(map[string]int)
+	newManager := func(member string) *PartitionManager {
+		manager, err := client.NewPartitionManager(group, member, 8,
+			WithPartitionExpiration(time.Second),
+			WithRebalanceInterval(50*time.Millisecond),
+			WithOnChange(func(acquired, released []int) {
+				mu.Lock()
+				defer mu.Unlock()
+				changes[member] += len(acquired) - len(released)
+			}),
+		)
+		if err != nil {
+			t.Fatalf("NewPartitionManager error:[%v]", err)
+		}
+		return manager
+	}
+
+	managerOne := newManager("memberOne")
+	managerTwo := newManager("memberTwo")
+
+	ctxOne, cancelOne := context.WithCancel(context.Background())
+	defer cancelOne()
+	doneOne := make(chan struct{})
+	go func() {
+		defer close(doneOne)
+		_ = managerOne.Run(ctxOne)
+	}()
+
+	t.Run("SingleMember", func(t *testing.T) {
+		waitOwned(t, managerOne, 8)
+	})
+
+	ctxTwo, cancelTwo := context.WithCancel(context.Background())
+	defer cancelTwo()
+	doneTwo := make(chan struct{})
+	go func() {
+		defer close(doneTwo)
+		_ = managerTwo.Run(ctxTwo)
+	}()
+
+	t.Run("NewMemberJoins", func(t *testing.T) {
+		waitOwned(t, managerOne, 4)
+		waitOwned(t, managerTwo, 4)
+
+		owned := make(map[int]bool)
+		for _, partition := range append(managerOne.Owned(), managerTwo.Owned()...) {
+			if owned[partition] {
+				t.Errorf("partition %v is owned by both members", partition)
+			}
+			owned[partition] = true
+		}
+	})
+
+	t.Run("MemberLeaves", func(t *testing.T) {
+		cancelTwo()
+		<-doneTwo
+		waitOwned(t, managerOne, 8)
+	})
+
+	cancelOne()
+	<-doneOne
+
+	mu.Lock()
+	defer mu.Unlock()
+	for member, count := range changes {
+		if count != 0 {
+			t.Errorf("changes of %v do not sum to zero after leaving, got %v", member, count)
+		}
+	}
+}
+
+func TestNewPartitionManager(t *testing.T) {
+	client, err := NewDefaultClient(redis.NewClient(&redis.Options{Addr: ":6379"}))
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+
+	for _, partitions := range []int{0, -1} {
+		if _, err = client.NewPartitionManager("testGroup", "member", partitions); !IsInvalidPartitions(err) {
+			t.Errorf("NewPartitionManager error is not equal,expected %v, got %v", ErrInvalidPartitions, err)
+		}
+	}
+}
+
+func TestPartitionManager_share(t *testing.T) {
+	client, err := NewDefaultClient(redis.NewClient(&redis.Options{Addr: ":6379"}))
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+
+	// test cases
+	tests := []struct {
+		Name       string
+		Partitions int
+		Members    []string
+		Expected   []int // shares of the members in descending order.
+	}{
+		{"SingleMember", 8, []string{"a"}, []int{8}},
+		{"EvenShares", 8, []string{"a", "b"}, []int{4, 4}},
+		{"UnevenShares", 10, []string{"a", "b", "c", "d"}, []int{3, 3, 2, 2}},
+		{"MoreMembersThanPartitions", 2, []string{"a", "b", "c"}, []int{1, 1, 0}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			var shares []int
+			sum := 0
+			for _, member := range tc.Members {
+				manager, err := client.NewPartitionManager("testGroup", member, tc.Partitions)
+				if err != nil {
+					t.Fatalf("NewPartitionManager error:[%v]", err)
+				}
+				share := manager.share(append([]string(nil), tc.Members...))
+				shares = append(shares, share)
+				sum += share
+			}
+
+			if sum != tc.Partitions {
+				t.Fatalf("sum of shares is not equal,expected %v, got %v", tc.Partitions, sum)
+			}
+
+			sort.Sort(sort.Reverse(sort.IntSlice(shares)))
+			for i := range shares {
+				if shares[i] != tc.Expected[i] {
+					t.Fatalf("shares is not equal,expected %v, got %v", tc.Expected, shares)
+				}
+			}
+		})
+	}
+}
+
+func waitOwned(t *testing.T, manager *PartitionManager, count int) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if len(manager.Owned()) == count {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("owned partitions is not equal,expected %v, got %v", count, manager.Owned())
+}
@@ -0,0 +1,52 @@
+package redislock
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// subscriber is implemented by go-redis clients supporting pub/sub, such as *redis.Client,
+// redislock waits for notifications through it, otherwise it polls.
+type subscriber interface {
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+// subscribe subscribes channel through s, the returned channel receives a value after messages are published,
+// close unsubscribes channel.
+func subscribe(ctx context.Context, s subscriber, channel string) (notify <-chan struct{}, close func(), err error) {
+	pubSub := s.Subscribe(ctx, channel)
+	// wait for the confirmation, so no message published afterwards is missed.
+	if _, err = pubSub.Receive(ctx); err != nil {
+		_ = pubSub.Close()
+		return nil, nil, err
+	}
+
+	messages := pubSub.Channel()
+	ch := make(chan struct{}, 1)
+	go func() {
+		for range messages {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return ch, func() { _ = pubSub.Close() }, nil
+}
+
+// waitNotified waits until notify receives a value, pollInterval passes or ctx is done.
+func (c *Client) waitNotified(ctx context.Context, notify <-chan struct{}, pollInterval time.Duration) error {
+	timer, stop := c.clock.NewTimer(pollInterval)
+	defer stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-notify:
+	case <-timer:
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+module github.com/XdpCs/redis-lock/redislockotel
+
+go 1.18
+
+replace github.com/XdpCs/redis-lock => ../
+
+require (
+	github.com/XdpCs/redis-lock v0.0.0-20230719094903-e79ff7e15277
+	go.opentelemetry.io/otel v1.14.0
+	go.opentelemetry.io/otel/sdk v1.14.0
+	go.opentelemetry.io/otel/trace v1.14.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/go-logr/logr v1.2.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/redis/go-redis/v9 v9.0.5 // indirect
+	golang.org/x/sys v0.5.0 // indirect
+)
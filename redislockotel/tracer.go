@@ -0,0 +1,97 @@
+// Package redislockotel provides an OpenTelemetry implementation of redislock.Tracer.
+package redislockotel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the OpenTelemetry tracer.
+const instrumentationName = "github.com/XdpCs/redis-lock/redislockotel"
+
+// Tracer is a redislock.Tracer creating OpenTelemetry spans.
+type Tracer struct {
+	tracerProvider trace.TracerProvider // default is otel.GetTracerProvider().
+	tracer         trace.Tracer
+}
+
+// NewTracer creates a new Tracer.
+func NewTracer(options ...Option) *Tracer {
+	t := &Tracer{tracerProvider: otel.GetTracerProvider()}
+
+	for _, option := range options {
+		option(t)
+	}
+
+	t.tracer = t.tracerProvider.Tracer(instrumentationName)
+	return t
+}
+
+type Option func(tracer *Tracer)
+
+// WithTracerProvider sets the tracer provider of the tracer.
+func WithTracerProvider(tracerProvider trace.TracerProvider) Option {
+	return func(tracer *Tracer) {
+		tracer.tracerProvider = tracerProvider
+	}
+}
+
+// Start starts a span for operation on key as a child of the span in ctx.
+func (t *Tracer) Start(ctx context.Context, operation, key string) (context.Context, redislock.Span) {
+	ctx, s := t.tracer.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String(redislock.AttributeKey, key),
+		),
+	)
+
+	return ctx, &span{span: s}
+}
+
+// span wraps trace.Span as redislock.Span.
+type span struct {
+	span trace.Span
+}
+
+// SetAttribute sets an attribute on the span.
+func (s *span) SetAttribute(key string, value interface{}) {
+	s.span.SetAttributes(toAttribute(key, value))
+}
+
+// End records err on the span and ends it.
+func (s *span) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+func toAttribute(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case uint:
+		return attribute.Int64(key, int64(v))
+	case float64:
+		return attribute.Float64(key, v)
+	case time.Duration:
+		return attribute.Int64(key, v.Milliseconds())
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,102 @@
+package redislockotel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer_Start(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := NewTracer(WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))))
+
+	_, spanOne := tracer.Start(context.Background(), redislock.OperationTryLock, "testOne")
+	spanOne.SetAttribute(redislock.AttributeRetryCount, 2)
+	spanOne.SetAttribute(redislock.AttributeOutcome, redislock.OutcomeAcquired)
+	spanOne.End(nil)
+
+	_, spanTwo := tracer.Start(context.Background(), redislock.OperationUnlock, "testTwo")
+	spanTwo.End(redislock.ErrMutexNotHeld)
+
+	ended := recorder.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("ended spans is not equal,expected %v, got %v", 2, len(ended))
+	}
+
+	// test cases
+	cases := []struct {
+		Name       string
+		SpanName   string
+		Attributes []attribute.KeyValue
+		Status     codes.Code
+	}{
+		{
+			"TryLockSpan",
+			redislock.OperationTryLock,
+			[]attribute.KeyValue{
+				attribute.String(redislock.AttributeKey, "testOne"),
+				attribute.Int(redislock.AttributeRetryCount, 2),
+				attribute.String(redislock.AttributeOutcome, redislock.OutcomeAcquired),
+			},
+			codes.Unset,
+		},
+		{
+			"UnlockSpanWithError",
+			redislock.OperationUnlock,
+			[]attribute.KeyValue{
+				attribute.String(redislock.AttributeKey, "testTwo"),
+			},
+			codes.Error,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			actual := ended[i]
+			if actual.Name() != c.SpanName {
+				t.Errorf("span name is not equal,expected %v, got %v", c.SpanName, actual.Name())
+			}
+
+			if actual.Status().Code != c.Status {
+				t.Errorf("span status is not equal,expected %v, got %v", c.Status, actual.Status().Code)
+			}
+
+			attributes := make(map[attribute.Key]attribute.Value)
+			for _, kv := range actual.Attributes() {
+				attributes[kv.Key] = kv.Value
+			}
+			for _, kv := range c.Attributes {
+				if attributes[kv.Key] != kv.Value {
+					t.Errorf("attribute %v is not equal,expected %v, got %v", kv.Key, kv.Value.Emit(), attributes[kv.Key].Emit())
+				}
+			}
+		})
+	}
+}
+
+func TestToAttribute(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  attribute.Value
+	}{
+		{"String", "acquired", attribute.StringValue("acquired")},
+		{"Int", 3, attribute.IntValue(3)},
+		{"Bool", true, attribute.BoolValue(true)},
+		{"Duration", 2 * time.Second, attribute.Int64Value(2000)},
+		{"Other", []byte("a"), attribute.StringValue("[97]")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toAttribute("key", tt.value).Value; got != tt.want {
+				t.Errorf("toAttribute() = %v, want %v", got.Emit(), tt.want.Emit())
+			}
+		})
+	}
+}
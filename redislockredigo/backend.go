@@ -0,0 +1,298 @@
+// Package redislockredigo lets redislock use a redigo connection pool.
+package redislockredigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/gomodule/redigo/redis"
+)
+
+// Backend is a redislock.Backend using a redigo connection pool.
+type Backend struct {
+	pool *redis.Pool
+}
+
+// NewBackend creates a new Backend using pool.
+func NewBackend(pool *redis.Pool) *Backend {
+	return &Backend{pool: pool}
+}
+
+// NewClient creates a new redislock client using pool.
+func NewClient(pool *redis.Pool, options ...redislock.ClientOption) (*redislock.Client, error) {
+	return redislock.NewClientWithBackend(NewBackend(pool), options...)
+}
+
+// NewDefaultClient creates a new default redislock client using pool.
+func NewDefaultClient(pool *redis.Pool) (*redislock.Client, error) {
+	return NewClient(pool, redislock.WithCipherKey("1118"))
+}
+
+// Eval runs script by EVALSHA, falling back to EVAL if the script is not loaded.
+func (b *Backend) Eval(ctx context.Context, script *redislock.Script, keys []string, args ...interface{}) (interface{}, error) {
+	conn, err := b.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return eval(ctx, conn, script, keys, args)
+}
+
+// EvalWait runs script like Eval, then WAIT numReplicas timeout on the same connection.
+func (b *Backend) EvalWait(ctx context.Context, script *redislock.Script, keys []string, args []interface{}, numReplicas int, timeout time.Duration) (interface{}, int64, error) {
+	conn, err := b.pool.GetContext(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer conn.Close()
+
+	reply, err := eval(ctx, conn, script, keys, args)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	acked, err := redis.Int64(redis.DoContext(conn, ctx, "WAIT", numReplicas, timeout.Milliseconds()))
+	return reply, acked, err
+}
+
+// EvalSha runs script by EVALSHA only, it returns the NOSCRIPT error if the script is not loaded.
+func (b *Backend) EvalSha(ctx context.Context, script *redislock.Script, keys []string, args ...interface{}) (interface{}, error) {
+	conn, err := b.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	reply, err := redis.DoContext(conn, ctx, "EVALSHA", scriptArgs(script.Hash(), keys, args)...)
+	if err != nil {
+		return nil, err
+	}
+	return convert(reply), nil
+}
+
+// ScriptLoad loads script into the script cache.
+func (b *Backend) ScriptLoad(ctx context.Context, script *redislock.Script) error {
+	conn, err := b.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = redis.DoContext(conn, ctx, "SCRIPT", "LOAD", script.Source())
+	return err
+}
+
+// ScriptExists reports whether each of scripts is in the script cache.
+func (b *Backend) ScriptExists(ctx context.Context, scripts ...*redislock.Script) ([]bool, error) {
+	conn, err := b.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	argv := make([]interface{}, 0, 1+len(scripts))
+	argv = append(argv, "EXISTS")
+	for _, script := range scripts {
+		argv = append(argv, script.Hash())
+	}
+	replies, err := redis.Ints(redis.DoContext(conn, ctx, "SCRIPT", argv...))
+	if err != nil {
+		return nil, err
+	}
+
+	exists := make([]bool, len(replies))
+	for i, reply := range replies {
+		exists[i] = reply == 1
+	}
+	return exists, nil
+}
+
+// FCall calls function with keys and args.
+func (b *Backend) FCall(ctx context.Context, function string, keys []string, args ...interface{}) (interface{}, error) {
+	conn, err := b.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	reply, err := redis.DoContext(conn, ctx, "FCALL", scriptArgs(function, keys, args)...)
+	if err != nil {
+		return nil, err
+	}
+	return convert(reply), nil
+}
+
+// FunctionLoad loads library by FUNCTION LOAD REPLACE.
+func (b *Backend) FunctionLoad(ctx context.Context, library string) error {
+	conn, err := b.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = redis.DoContext(conn, ctx, "FUNCTION", "LOAD", "REPLACE", library)
+	return err
+}
+
+// SetNX sets key to value with expiration if key does not exist.
+func (b *Backend) SetNX(ctx context.Context, key, value string, expiration time.Duration) (bool, error) {
+	conn, err := b.pool.GetContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	var reply interface{}
+	if expiration > 0 {
+		reply, err = redis.DoContext(conn, ctx, "SET", key, value, "NX", "PX", expiration.Milliseconds())
+	} else {
+		reply, err = redis.DoContext(conn, ctx, "SET", key, value, "NX")
+	}
+
+	if err != nil {
+		return false, err
+	}
+	return reply != nil, nil
+}
+
+// Get returns the string value of key, found is false if key does not exist.
+func (b *Backend) Get(ctx context.Context, key string) (string, bool, error) {
+	conn, err := b.pool.GetContext(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	defer conn.Close()
+
+	value, err := redis.String(redis.DoContext(conn, ctx, "GET", key))
+	if err == redis.ErrNil {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// Subscribe subscribes channel on a connection of the pool, which is held until close is called.
+// If the server rejects SUBSCRIBE, it returns a nil channel, so redislock polls instead.
+func (b *Backend) Subscribe(ctx context.Context, channel string) (<-chan struct{}, func(), error) {
+	conn, err := b.pool.GetContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	psc := redis.PubSubConn{Conn: conn}
+	if err = psc.Subscribe(channel); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	// wait for the confirmation, so no message published afterwards is missed.
+	switch reply := psc.ReceiveContext(ctx).(type) {
+	case redis.Subscription:
+	case redis.Error:
+		conn.Close()
+		return nil, func() {}, nil
+	case error:
+		conn.Close()
+		return nil, nil, reply
+	default:
+		conn.Close()
+		return nil, nil, fmt.Errorf("redislockredigo: unexpected reply %v to SUBSCRIBE", reply)
+	}
+
+	ch := make(chan struct{}, 1)
+	go func() {
+		defer conn.Close()
+		for {
+			switch reply := psc.ReceiveWithTimeout(0).(type) {
+			case redis.Message:
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+			case redis.Subscription:
+				if reply.Count == 0 {
+					return
+				}
+			case error:
+				return
+			}
+		}
+	}()
+
+	return ch, func() { _ = psc.Unsubscribe(channel) }, nil
+}
+
+// eval runs script on conn by EVALSHA, falling back to EVAL if the script is not loaded.
+func eval(ctx context.Context, conn redis.Conn, script *redislock.Script, keys []string, args []interface{}) (interface{}, error) {
+	argv := scriptArgs(script.Hash(), keys, args)
+	reply, err := redis.DoContext(conn, ctx, "EVALSHA", argv...)
+	if isNoScript(err) {
+		argv[0] = script.Source()
+		reply, err = redis.DoContext(conn, ctx, "EVAL", argv...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return convert(reply), nil
+}
+
+// scriptArgs returns the arguments of EVALSHA, EVAL or FCALL, script is the sha1 hash, the source or the function.
+func scriptArgs(script string, keys []string, args []interface{}) []interface{} {
+	argv := make([]interface{}, 0, 2+len(keys)+len(args))
+	argv = append(argv, script, len(keys))
+	for _, key := range keys {
+		argv = append(argv, key)
+	}
+	for _, arg := range args {
+		argv = append(argv, toString(arg))
+	}
+	return argv
+}
+
+// isNoScript reports whether err is the NOSCRIPT error reply.
+func isNoScript(err error) bool {
+	var replyErr redis.Error
+	return errors.As(err, &replyErr) && strings.HasPrefix(string(replyErr), "NOSCRIPT")
+}
+
+// convert converts a reply of redigo like go-redis does, bulk strings are strings instead of []byte.
+func convert(reply interface{}) interface{} {
+	switch v := reply.(type) {
+	case []byte:
+		return string(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = convert(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// toString formats an argument of a script like go-redis does.
+func toString(arg interface{}) string {
+	switch v := arg.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,421 @@
+package redislockredigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/XdpCs/redis-lock/redislocktest"
+	"github.com/gomodule/redigo/redis"
+)
+
+// fakeConn is a redigo connection to a redislocktest.Redis.
+type fakeConn struct {
+	rdb *redislocktest.Redis
+
+	mu      sync.Mutex
+	pending []reply // replies of the sent commands, not received yet.
+}
+
+type reply struct {
+	value interface{}
+	err   error
+}
+
+func newTestPool(rdb *redislocktest.Redis) *redis.Pool {
+	return &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return &fakeConn{rdb: rdb}, nil
+		},
+	}
+}
+
+func (c *fakeConn) Do(commandName string, args ...interface{}) (interface{}, error) {
+	if err := c.Send(commandName, args...); err != nil {
+		return nil, err
+	}
+
+	// Do("") receives the replies of the sent commands, like a redigo connection.
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+
+	var last reply
+	for _, r := range pending {
+		last = r
+	}
+	return last.value, last.err
+}
+
+func (c *fakeConn) DoContext(ctx context.Context, commandName string, args ...interface{}) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Do(commandName, args...)
+}
+
+func (c *fakeConn) DoWithTimeout(_ time.Duration, commandName string, args ...interface{}) (interface{}, error) {
+	return c.Do(commandName, args...)
+}
+
+func (c *fakeConn) Send(commandName string, args ...interface{}) error {
+	if commandName == "" {
+		return nil
+	}
+
+	argv := make([]string, 0, 1+len(args))
+	argv = append(argv, commandName)
+	for _, arg := range args {
+		argv = append(argv, fmt.Sprint(arg))
+	}
+
+	value, err := c.rdb.Do(argv...)
+	if err != nil {
+		err = redis.Error(err.Error())
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending = append(c.pending, reply{value: value, err: err})
+	return nil
+}
+
+func (c *fakeConn) Flush() error {
+	return nil
+}
+
+func (c *fakeConn) Receive() (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.pending) == 0 {
+		return nil, errors.New("fakeConn: no pending reply")
+	}
+	r := c.pending[0]
+	c.pending = c.pending[1:]
+	return r.value, r.err
+}
+
+func (c *fakeConn) ReceiveContext(ctx context.Context) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Receive()
+}
+
+func (c *fakeConn) ReceiveWithTimeout(time.Duration) (interface{}, error) {
+	return c.Receive()
+}
+
+func (c *fakeConn) Err() error {
+	return nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func TestBackend_Mutex(t *testing.T) {
+	pool := newTestPool(redislocktest.NewRedis())
+	defer pool.Close()
+	client, err := NewClient(pool, redislock.WithFencing())
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+
+	ctx := context.Background()
+	key := "test"
+	mutex, err := client.TryLock(ctx, key, 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+	if mutex.FencingToken() == 0 {
+		t.Fatalf("FencingToken is zero")
+	}
+
+	if _, err = client.TryLock(ctx, key, 10*time.Second); !redislock.IsMutexLockFailed(err) {
+		t.Fatalf("TryLock is not equal,expected %v, got %v", redislock.ErrMutexLockFailed, err)
+	}
+
+	ttl, err := mutex.TTL(ctx)
+	if err != nil {
+		t.Fatalf("TTL error:[%v]", err)
+	}
+	if ttl <= 0 || ttl > 10*time.Second {
+		t.Fatalf("TTL is not in (0, %v], got %v", 10*time.Second, ttl)
+	}
+
+	if err = mutex.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+	if err = mutex.Unlock(ctx); !redislock.IsMutexNotHeld(err) {
+		t.Fatalf("Unlock is not equal,expected %v, got %v", redislock.ErrMutexNotHeld, err)
+	}
+}
+
+func TestBackend_SetNX(t *testing.T) {
+	clock := redislocktest.NewFakeClock(time.Unix(1700000000, 0))
+	pool := newTestPool(redislocktest.NewRedis(redislocktest.WithClock(clock)))
+	defer pool.Close()
+	client, err := NewDefaultClient(pool)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+
+	ctx := context.Background()
+	key := "test"
+	if _, err = client.TryLock(ctx, key, 10*time.Second); err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+	if _, err = client.TryLock(ctx, key, 10*time.Second); !redislock.IsMutexLockFailed(err) {
+		t.Fatalf("TryLock is not equal,expected %v, got %v", redislock.ErrMutexLockFailed, err)
+	}
+
+	// SET NX PX expires the lock.
+	clock.Advance(10 * time.Second)
+	if _, err = client.TryLock(ctx, key, 10*time.Second); err != nil {
+		t.Fatalf("TryLock after expiration error:[%v]", err)
+	}
+}
+
+func TestBackend_Get(t *testing.T) {
+	pool := newTestPool(redislocktest.NewRedis())
+	defer pool.Close()
+	backend := NewBackend(pool)
+
+	ctx := context.Background()
+	if _, found, err := backend.Get(ctx, "test"); err != nil || found {
+		t.Fatalf("Get is not equal,expected not found, got found %v, error:[%v]", found, err)
+	}
+
+	if _, err := backend.SetNX(ctx, "test", "value", time.Minute); err != nil {
+		t.Fatalf("SetNX error:[%v]", err)
+	}
+	value, found, err := backend.Get(ctx, "test")
+	if err != nil {
+		t.Fatalf("Get error:[%v]", err)
+	}
+	if !found || value != "value" {
+		t.Fatalf("Get is not equal,expected %v, got %v", "value", value)
+	}
+}
+
+func TestBackend_RWMutex(t *testing.T) {
+	pool := newTestPool(redislocktest.NewRedis())
+	defer pool.Close()
+	client, err := NewDefaultClient(pool)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+
+	ctx := context.Background()
+	key := "test"
+	readerOne, err := client.TryReadLock(ctx, key, 10*time.Second, redislock.NewNoRetry())
+	if err != nil {
+		t.Fatalf("readerOne TryReadLock error:[%v]", err)
+	}
+	readerTwo, err := client.TryReadLock(ctx, key, 10*time.Second, redislock.NewNoRetry())
+	if err != nil {
+		t.Fatalf("readerTwo TryReadLock error:[%v]", err)
+	}
+	if _, err = client.TryWriteLock(ctx, key, 10*time.Second, redislock.NewNoRetry()); !redislock.IsMutexLockFailed(err) {
+		t.Fatalf("TryWriteLock is not equal,expected %v, got %v", redislock.ErrMutexLockFailed, err)
+	}
+
+	if err = readerOne.Unlock(ctx); err != nil {
+		t.Fatalf("readerOne Unlock error:[%v]", err)
+	}
+	if err = readerTwo.Upgrade(ctx, redislock.NewNoRetry()); err != nil {
+		t.Fatalf("readerTwo Upgrade error:[%v]", err)
+	}
+	if !readerTwo.IsWriter() {
+		t.Fatalf("IsWriter is not equal,expected %v, got %v", true, false)
+	}
+	if err = readerTwo.Unlock(ctx); err != nil {
+		t.Fatalf("readerTwo Unlock error:[%v]", err)
+	}
+}
+
+func TestBackend_NoScript(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	pool := newTestPool(rdb)
+	defer pool.Close()
+	client, err := NewDefaultClient(pool)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+
+	ctx := context.Background()
+	mutex, err := client.TryLock(ctx, "test", 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+
+	// EVALSHA fails with NOSCRIPT, the script is sent again by EVAL.
+	rdb.ScriptFlush()
+	if err = mutex.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh error:[%v]", err)
+	}
+	if err = mutex.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+}
+
+func TestBackend_Subscribe(t *testing.T) {
+	pool := newTestPool(redislocktest.NewRedis())
+	defer pool.Close()
+	client, err := NewDefaultClient(pool)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the fake rejects SUBSCRIBE, so the waiter polls the latch.
+	latch := client.NewCountDownLatch("test", time.Minute)
+	if _, err = latch.TrySetCount(ctx, 1); err != nil {
+		t.Fatalf("TrySetCount error:[%v]", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- latch.Await(ctx)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	if err = latch.CountDown(ctx); err != nil {
+		t.Fatalf("CountDown error:[%v]", err)
+	}
+	if err = <-done; err != nil {
+		t.Fatalf("Await error:[%v]", err)
+	}
+}
+
+func TestBackend_Warmup(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	pool := newTestPool(rdb)
+	defer pool.Close()
+	client, err := NewDefaultClient(pool)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+
+	ctx := context.Background()
+	if err = client.Warmup(ctx); err != nil {
+		t.Fatalf("Warmup error:[%v]", err)
+	}
+	if missing, err := client.MissingScripts(ctx); err != nil || len(missing) != 0 {
+		t.Fatalf("MissingScripts is not equal,expected %v, got %v, error:[%v]", nil, missing, err)
+	}
+
+	rdb.ScriptFlush()
+	missing, err := client.MissingScripts(ctx)
+	if err != nil {
+		t.Fatalf("MissingScripts error:[%v]", err)
+	}
+	if len(missing) == 0 {
+		t.Fatalf("MissingScripts is empty after ScriptFlush")
+	}
+
+	// the NOSCRIPT of the unlock reloads all scripts in the background.
+	mutex, err := client.TryLock(ctx, "test", 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+	if err = mutex.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if missing, err = client.MissingScripts(ctx); err == nil && len(missing) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("MissingScripts is not equal,expected %v, got %v, error:[%v]", nil, missing, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBackend_Functions(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	pool := newTestPool(rdb)
+	defer pool.Close()
+	client, err := NewClient(pool, redislock.WithFunctions(), redislock.WithFencing())
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+
+	ctx := context.Background()
+	mutex, err := client.TryLock(ctx, "test", 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+	if err = mutex.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+
+	// the library is loaded on the first call and the scripts are not.
+	if version, err := rdb.Do("fcall", "redislock_version", "0"); err != nil || version != int64(redislock.LibraryVersion) {
+		t.Fatalf("version is not equal,expected %v, got %v, error:[%v]", redislock.LibraryVersion, version, err)
+	}
+	missing, err := client.MissingScripts(ctx)
+	if err != nil {
+		t.Fatalf("MissingScripts error:[%v]", err)
+	}
+	for _, name := range []string{"lock", "unlock"} {
+		found := false
+		for _, m := range missing {
+			found = found || m == name
+		}
+		if !found {
+			t.Fatalf("script %s is loaded, expected the function to be called", name)
+		}
+	}
+}
+
+func TestBackend_Wait(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	pool := newTestPool(rdb)
+	defer pool.Close()
+	client, err := NewClient(pool, redislock.WithWait(1, 100*time.Millisecond), redislock.WithWaitOnRefresh())
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+
+	ctx := context.Background()
+	rdb.SetReplicas(1)
+	mutex, err := client.TryLock(ctx, "test", 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+
+	// the replica is lost, acquires are rolled back and refreshes fail but keep the lock.
+	rdb.SetReplicas(0)
+	if _, err = client.TryLock(ctx, "other", 10*time.Second); !redislock.IsNotEnoughReplicas(err) {
+		t.Fatalf("TryLock is not equal,expected %v, got %v", redislock.ErrNotEnoughReplicas, err)
+	}
+	if exists, err := rdb.Do("exists", "other"); err != nil || exists != int64(0) {
+		t.Fatalf("Exists is not equal,expected %v, got %v, error:[%v]", 0, exists, err)
+	}
+	if _, err = client.TryReadLock(ctx, "other", 10*time.Second, redislock.NewNoRetry()); !redislock.IsNotEnoughReplicas(err) {
+		t.Fatalf("TryReadLock is not equal,expected %v, got %v", redislock.ErrNotEnoughReplicas, err)
+	}
+	if exists, err := rdb.Do("exists", "other"); err != nil || exists != int64(0) {
+		t.Fatalf("Exists is not equal,expected %v, got %v, error:[%v]", 0, exists, err)
+	}
+
+	if err = mutex.Refresh(ctx); !redislock.IsNotEnoughReplicas(err) {
+		t.Fatalf("Refresh is not equal,expected %v, got %v", redislock.ErrNotEnoughReplicas, err)
+	}
+	if err = mutex.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+}
@@ -0,0 +1,207 @@
+// Package redislockrueidis lets redislock use a rueidis client.
+package redislockrueidis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/redis/rueidis"
+)
+
+// Backend is a redislock.Backend using a rueidis client.
+type Backend struct {
+	client rueidis.Client
+}
+
+// NewBackend creates a new Backend using client.
+func NewBackend(client rueidis.Client) *Backend {
+	return &Backend{client: client}
+}
+
+// NewClient creates a new redislock client using client.
+func NewClient(client rueidis.Client, options ...redislock.ClientOption) (*redislock.Client, error) {
+	return redislock.NewClientWithBackend(NewBackend(client), options...)
+}
+
+// NewDefaultClient creates a new default redislock client using client.
+func NewDefaultClient(client rueidis.Client) (*redislock.Client, error) {
+	return NewClient(client, redislock.WithCipherKey("1118"))
+}
+
+// Eval runs script by EVALSHA, falling back to EVAL if the script is not loaded.
+func (b *Backend) Eval(ctx context.Context, script *redislock.Script, keys []string, args ...interface{}) (interface{}, error) {
+	reply, err := b.EvalSha(ctx, script, keys, args...)
+	if err == nil || !strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		return reply, err
+	}
+	return toReply(b.client.Do(ctx, b.client.B().Eval().Script(script.Source()).Numkeys(int64(len(keys))).Key(keys...).Arg(toStrings(args)...).Build()))
+}
+
+// EvalSha runs script by EVALSHA only, it returns the NOSCRIPT error if the script is not loaded.
+func (b *Backend) EvalSha(ctx context.Context, script *redislock.Script, keys []string, args ...interface{}) (interface{}, error) {
+	return toReply(b.client.Do(ctx, b.client.B().Evalsha().Sha1(script.Hash()).Numkeys(int64(len(keys))).Key(keys...).Arg(toStrings(args)...).Build()))
+}
+
+// ScriptLoad loads script into the script cache.
+func (b *Backend) ScriptLoad(ctx context.Context, script *redislock.Script) error {
+	return b.client.Do(ctx, b.client.B().ScriptLoad().Script(script.Source()).Build()).Error()
+}
+
+// ScriptExists reports whether each of scripts is in the script cache.
+func (b *Backend) ScriptExists(ctx context.Context, scripts ...*redislock.Script) ([]bool, error) {
+	hashes := make([]string, len(scripts))
+	for i, script := range scripts {
+		hashes[i] = script.Hash()
+	}
+
+	replies, err := b.client.Do(ctx, b.client.B().ScriptExists().Sha1(hashes...).Build()).AsIntSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	exists := make([]bool, len(replies))
+	for i, reply := range replies {
+		exists[i] = reply == 1
+	}
+	return exists, nil
+}
+
+// EvalWait runs script like Eval, then WAIT numReplicas timeout on a dedicated connection.
+func (b *Backend) EvalWait(ctx context.Context, script *redislock.Script, keys []string, args []interface{}, numReplicas int, timeout time.Duration) (reply interface{}, acked int64, err error) {
+	err = b.client.Dedicated(func(client rueidis.DedicatedClient) error {
+		argv := toStrings(args)
+		result := client.Do(ctx, client.B().Evalsha().Sha1(script.Hash()).Numkeys(int64(len(keys))).Key(keys...).Arg(argv...).Build())
+		if err := result.Error(); err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT") {
+			result = client.Do(ctx, client.B().Eval().Script(script.Source()).Numkeys(int64(len(keys))).Key(keys...).Arg(argv...).Build())
+		}
+
+		var err error
+		if reply, err = toReply(result); err != nil {
+			return err
+		}
+		acked, err = client.Do(ctx, client.B().Wait().Numreplicas(int64(numReplicas)).Timeout(timeout.Milliseconds()).Build()).AsInt64()
+		return err
+	})
+	return reply, acked, err
+}
+
+// FCall calls function with keys and args.
+func (b *Backend) FCall(ctx context.Context, function string, keys []string, args ...interface{}) (interface{}, error) {
+	return toReply(b.client.Do(ctx, b.client.B().Fcall().Function(function).Numkeys(int64(len(keys))).Key(keys...).Arg(toStrings(args)...).Build()))
+}
+
+// FunctionLoad loads library by FUNCTION LOAD REPLACE.
+func (b *Backend) FunctionLoad(ctx context.Context, library string) error {
+	return b.client.Do(ctx, b.client.B().FunctionLoad().Replace().FunctionCode(library).Build()).Error()
+}
+
+// toReply converts result like go-redis does, a nil reply is nil.
+func toReply(result rueidis.RedisResult) (interface{}, error) {
+	reply, err := result.ToAny()
+	if rueidis.IsRedisNil(err) {
+		return nil, nil
+	}
+	return reply, err
+}
+
+// SetNX sets key to value with expiration if key does not exist.
+func (b *Backend) SetNX(ctx context.Context, key, value string, expiration time.Duration) (bool, error) {
+	cmd := b.client.B().Set().Key(key).Value(value).Nx()
+	var err error
+	if expiration > 0 {
+		err = b.client.Do(ctx, cmd.PxMilliseconds(expiration.Milliseconds()).Build()).Error()
+	} else {
+		err = b.client.Do(ctx, cmd.Build()).Error()
+	}
+
+	if rueidis.IsRedisNil(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Get returns the string value of key, found is false if key does not exist.
+func (b *Backend) Get(ctx context.Context, key string) (string, bool, error) {
+	value, err := b.client.Do(ctx, b.client.B().Get().Key(key).Build()).ToString()
+	if rueidis.IsRedisNil(err) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// Subscribe subscribes channel on a dedicated connection.
+func (b *Backend) Subscribe(ctx context.Context, channel string) (<-chan struct{}, func(), error) {
+	dedicated, release := b.client.Dedicate()
+
+	ch := make(chan struct{}, 1)
+	subscribed := make(chan struct{})
+	var once sync.Once
+	closed := dedicated.SetPubSubHooks(rueidis.PubSubHooks{
+		OnMessage: func(rueidis.PubSubMessage) {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		},
+		OnSubscription: func(s rueidis.PubSubSubscription) {
+			if s.Kind == "subscribe" && s.Channel == channel {
+				once.Do(func() { close(subscribed) })
+			}
+		},
+	})
+
+	if err := dedicated.Do(ctx, dedicated.B().Subscribe().Channel(channel).Build()).Error(); err != nil {
+		release()
+		return nil, nil, err
+	}
+
+	// wait for the confirmation, so no message published afterwards is missed.
+	select {
+	case <-subscribed:
+	case err := <-closed:
+		release()
+		return nil, nil, fmt.Errorf("subscription closed: %w", err)
+	case <-ctx.Done():
+		release()
+		return nil, nil, ctx.Err()
+	}
+
+	return ch, release, nil
+}
+
+func toStrings(args []interface{}) []string {
+	argv := make([]string, len(args))
+	for i, arg := range args {
+		argv[i] = toString(arg)
+	}
+	return argv
+}
+
+// toString formats an argument of a script like go-redis does.
+func toString(arg interface{}) string {
+	switch v := arg.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,223 @@
+package redislockrueidis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/redis/rueidis"
+)
+
+func newTestClient(t *testing.T) rueidis.Client {
+	t.Helper()
+	client, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:  []string{"127.0.0.1:6379"},
+		DisableCache: true,
+	})
+	if err != nil {
+		t.Fatalf("rueidis.NewClient error:[%v]", err)
+	}
+	return client
+}
+
+func teardown(t *testing.T, client rueidis.Client, keys []string) {
+	t.Helper()
+	if err := client.Do(context.Background(), client.B().Del().Key(keys...).Build()).Error(); err != nil {
+		t.Fatalf("Del error:[%v]", err)
+	}
+}
+
+func TestBackend_Mutex(t *testing.T) {
+	rdb := newTestClient(t)
+	defer rdb.Close()
+	client, err := NewClient(rdb, redislock.WithFencing())
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+	key := "test"
+	defer teardown(t, rdb, []string{key, redislock.FencingKey(key)})
+
+	ctx := context.Background()
+	mutex, err := client.TryLock(ctx, key, 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+	if mutex.FencingToken() == 0 {
+		t.Fatalf("FencingToken is zero")
+	}
+
+	if _, err = client.TryLock(ctx, key, 10*time.Second); !redislock.IsMutexLockFailed(err) {
+		t.Fatalf("TryLock is not equal,expected %v, got %v", redislock.ErrMutexLockFailed, err)
+	}
+
+	ttl, err := mutex.TTL(ctx)
+	if err != nil {
+		t.Fatalf("TTL error:[%v]", err)
+	}
+	if ttl <= 0 || ttl > 10*time.Second {
+		t.Fatalf("TTL is not in (0, %v], got %v", 10*time.Second, ttl)
+	}
+
+	if err = mutex.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+	if err = mutex.Unlock(ctx); !redislock.IsMutexNotHeld(err) {
+		t.Fatalf("Unlock is not equal,expected %v, got %v", redislock.ErrMutexNotHeld, err)
+	}
+}
+
+func TestBackend_SetNX(t *testing.T) {
+	rdb := newTestClient(t)
+	defer rdb.Close()
+	client, err := NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+	key := "test"
+	defer teardown(t, rdb, []string{key})
+
+	ctx := context.Background()
+	if _, err = client.TryLock(ctx, key, 10*time.Second); err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+	if _, err = client.TryLock(ctx, key, 10*time.Second); !redislock.IsMutexLockFailed(err) {
+		t.Fatalf("TryLock is not equal,expected %v, got %v", redislock.ErrMutexLockFailed, err)
+	}
+}
+
+func TestBackend_Get(t *testing.T) {
+	rdb := newTestClient(t)
+	defer rdb.Close()
+	backend := NewBackend(rdb)
+	key := "test"
+	defer teardown(t, rdb, []string{key})
+
+	ctx := context.Background()
+	if _, found, err := backend.Get(ctx, key); err != nil || found {
+		t.Fatalf("Get is not equal,expected not found, got found %v, error:[%v]", found, err)
+	}
+
+	if _, err := backend.SetNX(ctx, key, "value", time.Minute); err != nil {
+		t.Fatalf("SetNX error:[%v]", err)
+	}
+	value, found, err := backend.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get error:[%v]", err)
+	}
+	if !found || value != "value" {
+		t.Fatalf("Get is not equal,expected %v, got %v", "value", value)
+	}
+}
+
+func TestBackend_Subscribe(t *testing.T) {
+	rdb := newTestClient(t)
+	defer rdb.Close()
+	client, err := NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+	key := "test"
+	defer teardown(t, rdb, []string{key})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	latch := client.NewCountDownLatch(key, time.Minute)
+	if _, err = latch.TrySetCount(ctx, 1); err != nil {
+		t.Fatalf("TrySetCount error:[%v]", err)
+	}
+
+	// the waiter is notified through pub/sub long before the poll interval of the latch.
+	done := make(chan error, 1)
+	go func() {
+		done <- latch.Await(ctx)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	if err = latch.CountDown(ctx); err != nil {
+		t.Fatalf("CountDown error:[%v]", err)
+	}
+	if err = <-done; err != nil {
+		t.Fatalf("Await error:[%v]", err)
+	}
+	if elapsed := time.Since(start); elapsed >= redislock.DefaultPollInterval/2 {
+		t.Fatalf("Await is not notified, it returned after %v", elapsed)
+	}
+}
+
+func TestBackend_Warmup(t *testing.T) {
+	rdb := newTestClient(t)
+	defer rdb.Close()
+	client, err := NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+	key := "test"
+	defer teardown(t, rdb, []string{key})
+
+	ctx := context.Background()
+	if err = rdb.Do(ctx, rdb.B().ScriptFlush().Build()).Error(); err != nil {
+		t.Fatalf("ScriptFlush error:[%v]", err)
+	}
+	missing, err := client.MissingScripts(ctx)
+	if err != nil {
+		t.Fatalf("MissingScripts error:[%v]", err)
+	}
+	if len(missing) == 0 {
+		t.Fatalf("MissingScripts is empty after ScriptFlush")
+	}
+
+	if err = client.Warmup(ctx); err != nil {
+		t.Fatalf("Warmup error:[%v]", err)
+	}
+	if missing, err = client.MissingScripts(ctx); err != nil || len(missing) != 0 {
+		t.Fatalf("MissingScripts is not equal,expected %v, got %v, error:[%v]", nil, missing, err)
+	}
+}
+
+func TestBackend_Functions(t *testing.T) {
+	rdb := newTestClient(t)
+	defer rdb.Close()
+	// the client calls functions on redis 7 and falls back to scripts before.
+	client, err := NewClient(rdb, redislock.WithFunctions(), redislock.WithFencing())
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+	key := "test"
+	defer teardown(t, rdb, []string{key, redislock.FencingKey(key)})
+
+	ctx := context.Background()
+	mutex, err := client.TryLock(ctx, key, 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+	if err = mutex.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+}
+
+func TestBackend_Wait(t *testing.T) {
+	rdb := newTestClient(t)
+	defer rdb.Close()
+	client, err := NewClient(rdb, redislock.WithWait(1, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+	key := "test"
+	defer teardown(t, rdb, []string{key})
+
+	// the test redis has no replicas, so the acquire fails and is rolled back.
+	ctx := context.Background()
+	if _, err = client.TryLock(ctx, key, 10*time.Second); err == nil {
+		t.Fatalf("TryLock is not equal,expected an error, got nil")
+	}
+	exists, err := rdb.Do(ctx, rdb.B().Exists().Key(key).Build()).AsInt64()
+	if err != nil {
+		t.Fatalf("Exists error:[%v]", err)
+	}
+	if exists != 0 {
+		t.Fatalf("Exists is not equal,expected %v, got %v", 0, exists)
+	}
+}
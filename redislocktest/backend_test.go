@@ -0,0 +1,44 @@
+package redislocktest_test
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/redis/go-redis/v9"
+)
+
+// testBackend is a redislock.Backend of a RedisClient which implements none of the optional interfaces,
+// such as redislock.ScriptLoader.
+type testBackend struct {
+	client redislock.RedisClient
+}
+
+func (b testBackend) Eval(ctx context.Context, script *redislock.Script, keys []string, args ...interface{}) (interface{}, error) {
+	reply, err := b.client.EvalSha(ctx, script.Hash(), keys, args...).Result()
+	if err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		reply, err = b.client.Eval(ctx, script.Source(), keys, args...).Result()
+	}
+
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return reply, err
+}
+
+func (b testBackend) SetNX(ctx context.Context, key, value string, expiration time.Duration) (bool, error) {
+	return b.client.SetNX(ctx, key, value, expiration).Result()
+}
+
+func (b testBackend) Get(ctx context.Context, key string) (string, bool, error) {
+	value, err := b.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	return value, err == nil, err
+}
+
+func (b testBackend) Subscribe(ctx context.Context, channel string) (<-chan struct{}, func(), error) {
+	return nil, func() {}, nil
+}
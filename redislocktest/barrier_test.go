@@ -0,0 +1,75 @@
+package redislocktest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/XdpCs/redis-lock/redislocktest"
+)
+
+func newBarrierClient(t *testing.T) (*redislock.Client, *redislocktest.Redis, *redislocktest.FakeClock) {
+	clock := redislocktest.NewFakeClock(time.Unix(1700000000, 0))
+	rdb := redislocktest.NewRedis(redislocktest.WithClock(clock))
+	client, err := redislock.NewClient(rdb, redislock.WithClock(clock))
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+	return client, rdb, clock
+}
+
+func TestBarrier_Await_Waiting(t *testing.T) {
+	client, _, clock := newBarrierClient(t)
+	ctx := context.Background()
+
+	awaited := make(chan error, 1)
+	go func() {
+		awaited <- client.NewBarrier("test", 2, 3*time.Second).Await(ctx)
+	}()
+
+	// the waiting party keeps the barrier far beyond its expiration.
+	for i := 0; i < 10; i++ {
+		clock.WaitForTimers(1)
+		clock.Advance(time.Second)
+	}
+	clock.WaitForTimers(1)
+
+	if err := client.NewBarrier("test", 2, 3*time.Second).Await(ctx); err != nil {
+		t.Fatalf("Await error:[%v]", err)
+	}
+
+	clock.Advance(time.Second)
+	if err := <-awaited; err != nil {
+		t.Fatalf("Await error:[%v]", err)
+	}
+}
+
+func TestBarrier_Await_Broken(t *testing.T) {
+	client, rdb, clock := newBarrierClient(t)
+	ctx := context.Background()
+
+	awaited := make(chan error, 1)
+	go func() {
+		awaited <- client.NewBarrier("test", 2, 3*time.Second).Await(ctx)
+	}()
+
+	clock.WaitForTimers(1)
+	// the barrier is lost, the waiting party can not tell whether the other party arrived.
+	if _, err := rdb.Do("del", "test"); err != nil {
+		t.Fatalf("Do error:[%v]", err)
+	}
+	clock.Advance(time.Second)
+
+	if err := <-awaited; !redislock.IsBarrierBroken(err) {
+		t.Fatalf("Await error is not equal,expected %v, got %v", redislock.ErrBarrierBroken, err)
+	}
+
+	exists, err := rdb.Do("exists", "test")
+	if err != nil {
+		t.Fatalf("Do error:[%v]", err)
+	}
+	if exists != int64(0) {
+		t.Fatalf("exists is not equal,expected %v, got %v", 0, exists)
+	}
+}
@@ -0,0 +1,260 @@
+package redislocktest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/XdpCs/redis-lock/redislocktest"
+)
+
+func newFakeClockClient(t *testing.T) (*redislock.Client, *redislocktest.FakeClock) {
+	clock := redislocktest.NewFakeClock(time.Unix(1700000000, 0))
+	rdb := redislocktest.NewRedis(redislocktest.WithClock(clock))
+	client, err := redislock.NewClient(rdb, redislock.WithClock(clock))
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+	return client, clock
+}
+
+func TestClient_WithClock_WatchDog(t *testing.T) {
+	client, clock := newFakeClockClient(t)
+	ctx := context.Background()
+
+	mutex, err := client.TryLockWithWatchDog(ctx, "test", redislock.NewWatchDog(3*time.Second))
+	if err != nil {
+		t.Fatalf("TryLockWithWatchDog error:[%v]", err)
+	}
+	defer mutex.Unlock(ctx)
+
+	// the watch dog refreshes the lock every second, far beyond its expiration.
+	for i := 0; i < 10; i++ {
+		clock.WaitForTimers(1)
+		clock.Advance(time.Second)
+	}
+	// the refresh of the last tick is done once the watch dog waits again.
+	clock.WaitForTimers(1)
+
+	ttl, err := mutex.TTL(ctx)
+	if err != nil {
+		t.Fatalf("TTL error:[%v]", err)
+	}
+	if ttl != 3*time.Second {
+		t.Fatalf("TTL is not equal,expected %v, got %v", 3*time.Second, ttl)
+	}
+
+	expected := clock.Now().Add(3 * time.Second)
+	if deadline := mutex.Deadline(); !deadline.Equal(expected) {
+		t.Fatalf("Deadline is not equal,expected %v, got %v", expected, deadline)
+	}
+}
+
+func TestClient_WithClock_Retry(t *testing.T) {
+	client, clock := newFakeClockClient(t)
+	ctx := context.Background()
+
+	if _, err := client.TryLock(ctx, "test", 5*time.Second); err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+
+	type result struct {
+		mutex *redislock.Mutex
+		err   error
+	}
+	results := make(chan result, 1)
+	go func() {
+		mutex, err := client.TryLockWithRetryStrategy(ctx, "test", 10*time.Second, redislock.NewAverageRetry(10, time.Second))
+		results <- result{mutex, err}
+	}()
+
+	// the lock held by others expires after five retries.
+	for i := 0; i < 5; i++ {
+		clock.WaitForTimers(1)
+		clock.Advance(time.Second)
+	}
+
+	r := <-results
+	if r.err != nil {
+		t.Fatalf("TryLockWithRetryStrategy error:[%v]", r.err)
+	}
+
+	expected := time.Unix(1700000000, 0).Add(5 * time.Second)
+	if acquiredAt := r.mutex.AcquiredAt(); !acquiredAt.Equal(expected) {
+		t.Fatalf("AcquiredAt is not equal,expected %v, got %v", expected, acquiredAt)
+	}
+}
+
+func TestClient_WithClock_Deadline(t *testing.T) {
+	client, clock := newFakeClockClient(t)
+	ctx := context.Background()
+
+	if _, err := client.TryLock(ctx, "test", time.Minute); err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := client.TryLockWithRetryStrategy(ctx, "test", 3*time.Second, redislock.NewAverageRetry(10, time.Second))
+		errs <- err
+	}()
+
+	// the retries end with the expiration of the lock being acquired.
+	for i := 0; i < 3; i++ {
+		clock.WaitForTimers(1)
+		clock.Advance(time.Second)
+	}
+
+	if err := <-errs; err != context.DeadlineExceeded {
+		t.Fatalf("TryLockWithRetryStrategy is not equal,expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestMutex_Extend_WatchDog(t *testing.T) {
+	client, clock := newFakeClockClient(t)
+	ctx := context.Background()
+
+	mutex, err := client.TryLockWithWatchDog(ctx, "test", redislock.NewWatchDog(30*time.Second))
+	if err != nil {
+		t.Fatalf("TryLockWithWatchDog error:[%v]", err)
+	}
+	defer mutex.Unlock(ctx)
+
+	if _, err = mutex.Extend(ctx, 0); !redislock.IsInvalidExpiration(err) {
+		t.Fatalf("Extend is not equal,expected %v, got %v", redislock.ErrInvalidExpiration, err)
+	}
+
+	// the watch dog sleeping a third of 30s is rescheduled to a third of 3s.
+	if _, err = mutex.Extend(ctx, 3*time.Second); err != nil {
+		t.Fatalf("Extend error:[%v]", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		clock.WaitForTimers(1)
+		clock.Advance(time.Second)
+	}
+
+	held, err := mutex.IsHeld(ctx)
+	if err != nil {
+		t.Fatalf("IsHeld error:[%v]", err)
+	}
+	if !held {
+		t.Fatalf("IsHeld is not equal,expected %v, got %v", true, held)
+	}
+}
+
+func TestRWMutex_ReaderExpires(t *testing.T) {
+	client, clock := newFakeClockClient(t)
+	ctx := context.Background()
+
+	readerOne, err := client.TryReadLock(ctx, "test", 3*time.Second, redislock.NewNoRetry())
+	if err != nil {
+		t.Fatalf("readerOne TryReadLock error:[%v]", err)
+	}
+
+	// readerTwo crashes, it never refreshes or releases its read lock.
+	if _, err = client.TryReadLock(ctx, "test", 3*time.Second, redislock.NewNoRetry()); err != nil {
+		t.Fatalf("readerTwo TryReadLock error:[%v]", err)
+	}
+
+	if err = readerOne.Upgrade(ctx, redislock.NewNoRetry()); !redislock.IsMutexLockFailed(err) {
+		t.Fatalf("Upgrade is not equal,expected %v, got %v", redislock.ErrMutexLockFailed, err)
+	}
+
+	// readerOne keeps its read lock, the read lock of readerTwo expires.
+	for i := 0; i < 4; i++ {
+		clock.Advance(time.Second)
+		if err = readerOne.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh error:[%v]", err)
+		}
+	}
+
+	if err = readerOne.Upgrade(ctx, redislock.NewNoRetry()); err != nil {
+		t.Fatalf("Upgrade error:[%v]", err)
+	}
+	if !readerOne.IsWriter() {
+		t.Fatalf("IsWriter is not equal,expected %v, got %v", true, false)
+	}
+}
+
+func TestMutex_Extend_RefreshInFlight(t *testing.T) {
+	clock := redislocktest.NewFakeClock(time.Unix(1700000000, 0))
+	faulty := redislocktest.NewFaultyClient(redislocktest.NewRedis(redislocktest.WithClock(clock)), redislocktest.WithFaultyClock(clock))
+	client, err := redislock.NewClient(faulty, redislock.WithClock(clock))
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+	ctx := context.Background()
+
+	mutex, err := client.TryLockWithWatchDog(ctx, "test", redislock.NewWatchDog(30*time.Second))
+	if err != nil {
+		t.Fatalf("TryLockWithWatchDog error:[%v]", err)
+	}
+	defer mutex.Unlock(ctx)
+
+	// the first refresh of the watch dog is delayed by a second, Extend is called meanwhile.
+	faulty.Inject(redislocktest.CommandRefresh, redislocktest.Sequence(&redislocktest.Fault{Latency: time.Second}))
+	clock.WaitForTimers(1)
+	clock.Advance(10 * time.Second)
+	clock.WaitForTimers(1)
+
+	done := make(chan error)
+	go func() {
+		_, err := mutex.Extend(ctx, 3*time.Second)
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		t.Fatalf("Extend is not equal,expected to wait for the refresh in flight, got error:[%v]", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(time.Second)
+	if err = <-done; err != nil {
+		t.Fatalf("Extend error:[%v]", err)
+	}
+
+	// the refresh with the old expiration does not land after Extend.
+	ttl, err := mutex.TTL(ctx)
+	if err != nil {
+		t.Fatalf("TTL error:[%v]", err)
+	}
+	if ttl != 3*time.Second {
+		t.Fatalf("TTL is not equal,expected %v, got %v", 3*time.Second, ttl)
+	}
+}
+
+func TestMutex_TransferTo_RestartWatchDog(t *testing.T) {
+	clock := redislocktest.NewFakeClock(time.Unix(1700000000, 0))
+	faulty := redislocktest.NewFaultyClient(redislocktest.NewRedis(redislocktest.WithClock(clock)), redislocktest.WithFaultyClock(clock))
+	client, err := redislock.NewClient(faulty, redislock.WithClock(clock))
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+	ctx := context.Background()
+
+	mutex, err := client.TryLockWithWatchDog(ctx, "test", redislock.NewWatchDog(3*time.Second))
+	if err != nil {
+		t.Fatalf("TryLockWithWatchDog error:[%v]", err)
+	}
+	defer mutex.Unlock(ctx)
+
+	faulty.Inject("transfer", redislocktest.Always(redislocktest.Fault{Timeout: true}))
+	if _, err = mutex.TransferTo(ctx, "successor"); err != redislocktest.ErrTimeout {
+		t.Fatalf("TransferTo is not equal,expected %v, got %v", redislocktest.ErrTimeout, err)
+	}
+
+	// the restarted watch dog keeps the lock.
+	for i := 0; i < 10; i++ {
+		clock.WaitForTimers(1)
+		clock.Advance(time.Second)
+	}
+
+	held, err := mutex.IsHeld(ctx)
+	if err != nil {
+		t.Fatalf("IsHeld error:[%v]", err)
+	}
+	if !held {
+		t.Fatalf("IsHeld is not equal,expected %v, got %v", true, held)
+	}
+}
@@ -0,0 +1,196 @@
+package redislocktest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/XdpCs/redis-lock/redislocktest"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestClient_WithFunctions(t *testing.T) {
+	// test cases
+	tests := []struct {
+		Name      string
+		Library   string // the library loaded before the client starts.
+		Hide      bool   // hides the functions of the redis client.
+		Version   int64  // the version of the library afterwards, 0 if none is loaded.
+		Functions bool   // whether the client calls functions instead of scripts.
+	}{
+		{"Load", "", false, redislock.LibraryVersion, true},
+		{
+			"Upgrade",
+			"#!lua name=redislock\n" +
+				"redis.register_function('redislock_version', function() return 0 end)\n" +
+				"redis.register_function('redislock_v0_lock', function(KEYS, ARGV) return 0 end)",
+			false, redislock.LibraryVersion, true,
+		},
+		{
+			// a newer library is not downgraded, the client falls back to scripts.
+			"Newer",
+			fmt.Sprintf("#!lua name=redislock\n"+
+				"redis.register_function('redislock_version', function() return %[1]d end)\n"+
+				"redis.register_function('redislock_v%[1]d_lock', function(KEYS, ARGV) return 0 end)", redislock.LibraryVersion+1),
+			false, redislock.LibraryVersion + 1, false,
+		},
+		{"Unsupported", "", true, 0, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			rdb := redislocktest.NewRedis()
+			if tc.Library != "" {
+				if _, err := rdb.Do("function", "load", tc.Library); err != nil {
+					t.Fatalf("Do function load error:[%v]", err)
+				}
+			}
+
+			var redisClient redislock.RedisClient = rdb
+			if tc.Hide {
+				redisClient = struct{ redislock.RedisClient }{rdb}
+			}
+			client, err := redislock.NewClient(redisClient, redislock.WithFunctions(), redislock.WithFencing())
+			if err != nil {
+				t.Fatalf("NewClient error:[%v]", err)
+			}
+
+			ctx := context.Background()
+			mutex, err := client.TryLock(ctx, "test", 10*time.Second)
+			if err != nil {
+				t.Fatalf("TryLock error:[%v]", err)
+			}
+			if mutex.FencingToken() != 1 {
+				t.Fatalf("FencingToken is not equal,expected %v, got %v", 1, mutex.FencingToken())
+			}
+			if err = mutex.Refresh(ctx); err != nil {
+				t.Fatalf("Refresh error:[%v]", err)
+			}
+			if err = mutex.Unlock(ctx); err != nil {
+				t.Fatalf("Unlock error:[%v]", err)
+			}
+
+			var version int64
+			if reply, err := rdb.Do("fcall", "redislock_version", "0"); err == nil {
+				version = reply.(int64)
+			}
+			if version != tc.Version {
+				t.Fatalf("version is not equal,expected %v, got %v", tc.Version, version)
+			}
+
+			// the scripts are not loaded as long as the client calls functions.
+			missing, err := client.MissingScripts(ctx)
+			if err != nil {
+				t.Fatalf("MissingScripts error:[%v]", err)
+			}
+			if functions := !contains(missing, "lock"); functions == tc.Functions {
+				t.Fatalf("Functions is not equal,expected %v, got %v", tc.Functions, functions)
+			}
+		})
+	}
+}
+
+func TestClient_WithFunctions_Warmup(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	client, err := redislock.NewClient(rdb, redislock.WithFunctions())
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+
+	if err = client.Warmup(context.Background()); err != nil {
+		t.Fatalf("Warmup error:[%v]", err)
+	}
+	if reply, err := rdb.Do("fcall", "redislock_version", "0"); err != nil || reply != int64(redislock.LibraryVersion) {
+		t.Fatalf("version is not equal,expected %v, got %v, error:[%v]", redislock.LibraryVersion, reply, err)
+	}
+}
+
+func TestClient_WithFunctions_UnknownCommand(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	faulty := redislocktest.NewFaultyClient(rdb)
+	client, err := redislock.NewClient(faulty, redislock.WithFunctions(), redislock.WithFencing())
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+
+	// redis before 7 does not know FCALL, the client falls back to scripts.
+	errUnknownCommand := errors.New("ERR unknown command 'FCALL'")
+	faulty.Inject(redislocktest.CommandLock, redislocktest.Sequence(&redislocktest.Fault{Err: errUnknownCommand}))
+
+	ctx := context.Background()
+	mutex, err := client.TryLock(ctx, "test", 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+	if err = mutex.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+
+	missing, err := client.MissingScripts(ctx)
+	if err != nil {
+		t.Fatalf("MissingScripts error:[%v]", err)
+	}
+	if contains(missing, "lock") || contains(missing, "unlock") {
+		t.Fatalf("MissingScripts is not equal,expected the scripts are loaded, got %v", missing)
+	}
+}
+
+// fcallCounter is a Redis counting the calls of functions.
+type fcallCounter struct {
+	*redislocktest.Redis
+	fcalls int32
+}
+
+func (c *fcallCounter) FCall(ctx context.Context, function string, keys []string, args ...interface{}) *redis.Cmd {
+	atomic.AddInt32(&c.fcalls, 1)
+	return c.Redis.FCall(ctx, function, keys, args...)
+}
+
+func TestClient_WithFunctions_NewerLibrary(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	library := fmt.Sprintf("#!lua name=redislock\n"+
+		"redis.register_function('redislock_version', function() return %d end)", redislock.LibraryVersion+1)
+	if _, err := rdb.Do("function", "load", library); err != nil {
+		t.Fatalf("Do function load error:[%v]", err)
+	}
+
+	counter := &fcallCounter{Redis: rdb}
+	client, err := redislock.NewClient(counter, redislock.WithFunctions(), redislock.WithFencing())
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+
+	// the first operation probes the library, the function and the version.
+	ctx := context.Background()
+	mutex, err := client.TryLock(ctx, "test", 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+	if fcalls := atomic.LoadInt32(&counter.fcalls); fcalls != 2 {
+		t.Fatalf("fcalls is not equal,expected %v, got %v", 2, fcalls)
+	}
+
+	// the newer library is remembered, later operations run scripts only.
+	if err = mutex.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh error:[%v]", err)
+	}
+	if err = mutex.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+	if fcalls := atomic.LoadInt32(&counter.fcalls); fcalls != 2 {
+		t.Fatalf("fcalls is not equal,expected %v, got %v", 2, fcalls)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package redislocktest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/XdpCs/redis-lock/redislocktest"
+)
+
+func TestClient_Warmup(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	client, err := redislock.NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+
+	ctx := context.Background()
+	missing, err := client.MissingScripts(ctx)
+	if err != nil {
+		t.Fatalf("MissingScripts error:[%v]", err)
+	}
+	// no script is loaded in a new redis.
+	for _, name := range []string{"lock", "refresh", "unlock"} {
+		if !contains(missing, name) {
+			t.Fatalf("MissingScripts does not include %v, got %v", name, missing)
+		}
+	}
+
+	if err = client.Warmup(ctx); err != nil {
+		t.Fatalf("Warmup error:[%v]", err)
+	}
+	if missing, err = client.MissingScripts(ctx); err != nil || len(missing) != 0 {
+		t.Fatalf("MissingScripts is not equal,expected %v, got %v, error:[%v]", nil, missing, err)
+	}
+
+	// the first NOSCRIPT after a flush reloads all scripts in the background.
+	mutex, err := client.TryLock(ctx, "test", 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+	rdb.ScriptFlush()
+	if err = mutex.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+	waitReloaded(t, client)
+}
+
+// waitReloaded waits for the scripts of client to be reloaded in the background.
+func waitReloaded(t *testing.T, client *redislock.Client) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		missing, err := client.MissingScripts(context.Background())
+		if err == nil && len(missing) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("MissingScripts is not equal,expected %v, got %v, error:[%v]", nil, missing, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClient_NoScript_RetryBeforeReload(t *testing.T) {
+	clock := redislocktest.NewFakeClock(time.Unix(1700000000, 0))
+	rdb := redislocktest.NewRedis(redislocktest.WithClock(clock))
+	faulty := redislocktest.NewFaultyClient(rdb, redislocktest.WithFaultyClock(clock))
+	client, err := redislock.NewClient(faulty, redislock.WithClock(clock))
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+
+	ctx := context.Background()
+	mutex, err := client.TryLock(ctx, "test", 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+
+	// the reload hangs on its first SCRIPT LOAD until the clock is advanced.
+	faulty.Inject(redislocktest.CommandLoad, redislocktest.Sequence(&redislocktest.Fault{Latency: time.Hour}))
+	rdb.ScriptFlush()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- mutex.Unlock(ctx)
+	}()
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Fatalf("Unlock error:[%v]", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Unlock waits for the reload of the scripts")
+	}
+
+	clock.WaitForTimers(1)
+	clock.Advance(time.Hour)
+	waitReloaded(t, client)
+}
+
+func TestClient_NoScript(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	faulty := redislocktest.NewFaultyClient(rdb)
+	client, err := redislock.NewDefaultClient(faulty)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+
+	ctx := context.Background()
+	if err = client.Warmup(ctx); err != nil {
+		t.Fatalf("Warmup error:[%v]", err)
+	}
+	mutex, err := client.TryLock(ctx, "test", 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+
+	// a NOSCRIPT reply to EVALSHA is retried by EVAL, which is part of the same call.
+	errNoScript := errors.New("NOSCRIPT No matching script. Please use EVAL.")
+	faulty.Inject(redislocktest.CommandUnlock, redislocktest.Sequence(&redislocktest.Fault{Err: errNoScript}))
+	if err = mutex.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+
+	exists, err := rdb.Do("exists", "test")
+	if err != nil {
+		t.Fatalf("Do error:[%v]", err)
+	}
+	if exists != int64(0) {
+		t.Fatalf("exists is not equal,expected %v, got %v", 0, exists)
+	}
+}
+
+func TestClient_WarmupUnsupported(t *testing.T) {
+	// testBackend hides the ScriptLoader methods of the go-redis backend.
+	client, err := redislock.NewClientWithBackend(testBackend{client: redislocktest.NewRedis()})
+	if err != nil {
+		t.Fatalf("NewClientWithBackend error:[%v]", err)
+	}
+
+	ctx := context.Background()
+	if err = client.Warmup(ctx); !redislock.IsScriptLoaderUnsupported(err) {
+		t.Fatalf("Warmup is not equal,expected %v, got %v", redislock.ErrScriptLoaderUnsupported, err)
+	}
+	if _, err = client.MissingScripts(ctx); !redislock.IsScriptLoaderUnsupported(err) {
+		t.Fatalf("MissingScripts is not equal,expected %v, got %v", redislock.ErrScriptLoaderUnsupported, err)
+	}
+}
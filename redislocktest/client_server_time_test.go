@@ -0,0 +1,113 @@
+package redislocktest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/XdpCs/redis-lock/redislocktest"
+)
+
+// newSkewedClient returns a client with WithServerTime whose clock is an hour behind the clock of redis.
+func newSkewedClient(t *testing.T, options ...redislock.ClientOption) (*redislock.Client, *redislocktest.FakeClock, *redislocktest.FakeClock) {
+	serverClock := redislocktest.NewFakeClock(time.Unix(1700003600, 0))
+	clientClock := redislocktest.NewFakeClock(time.Unix(1700000000, 0))
+	rdb := redislocktest.NewRedis(redislocktest.WithClock(serverClock))
+	client, err := redislock.NewClient(rdb, append(options, redislock.WithServerTime(), redislock.WithClock(clientClock))...)
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+	return client, serverClock, clientClock
+}
+
+func TestClient_WithServerTime(t *testing.T) {
+	// test cases
+	cases := []struct {
+		Name         string
+		Options      []redislock.ClientOption
+		FencingToken int64
+	}{
+		{"Mutex", nil, 0},
+		{"MutexWithFencing", []redislock.ClientOption{redislock.WithFencing()}, 1},
+		{"MutexWithFunctions", []redislock.ClientOption{redislock.WithFunctions()}, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			client, serverClock, clientClock := newSkewedClient(t, c.Options...)
+			ctx := context.Background()
+
+			mutex, err := client.TryLock(ctx, "test", 3*time.Second)
+			if err != nil {
+				t.Fatalf("TryLock error:[%v]", err)
+			}
+			if fencingToken := mutex.FencingToken(); fencingToken != c.FencingToken {
+				t.Fatalf("FencingToken is not equal,expected %v, got %v", c.FencingToken, fencingToken)
+			}
+
+			expected := serverClock.Now().Add(3 * time.Second)
+			if deadline := mutex.ServerDeadline(); !deadline.Equal(expected) {
+				t.Fatalf("ServerDeadline is not equal,expected %v, got %v", expected, deadline)
+			}
+			expected = clientClock.Now().Add(3 * time.Second)
+			if deadline := mutex.Deadline(); !deadline.Equal(expected) {
+				t.Fatalf("Deadline is not equal,expected %v, got %v", expected, deadline)
+			}
+
+			serverClock.Advance(time.Second)
+			clientClock.Advance(time.Second)
+			if _, err = mutex.Extend(ctx, 5*time.Second); err != nil {
+				t.Fatalf("Extend error:[%v]", err)
+			}
+
+			expected = serverClock.Now().Add(5 * time.Second)
+			if deadline := mutex.ServerDeadline(); !deadline.Equal(expected) {
+				t.Fatalf("ServerDeadline is not equal,expected %v, got %v", expected, deadline)
+			}
+			expected = clientClock.Now().Add(5 * time.Second)
+			if deadline := mutex.Deadline(); !deadline.Equal(expected) {
+				t.Fatalf("Deadline is not equal,expected %v, got %v", expected, deadline)
+			}
+
+			// the lock expires in redis, whatever the clock of the client says.
+			serverClock.Advance(5 * time.Second)
+			if err = mutex.Refresh(ctx); !redislock.IsMutexNotHeld(err) {
+				t.Fatalf("Refresh is not equal,expected %v, got %v", redislock.ErrMutexNotHeld, err)
+			}
+		})
+	}
+}
+
+func TestClient_WithServerTime_WatchDog(t *testing.T) {
+	client, serverClock, clientClock := newSkewedClient(t)
+	ctx := context.Background()
+
+	mutex, err := client.TryLockWithWatchDog(ctx, "test", redislock.NewWatchDog(3*time.Second))
+	if err != nil {
+		t.Fatalf("TryLockWithWatchDog error:[%v]", err)
+	}
+	defer mutex.Unlock(ctx)
+
+	// the watch dog refreshes the lock every second, a third of the observed PTTL.
+	for i := 0; i < 10; i++ {
+		clientClock.WaitForTimers(1)
+		serverClock.Advance(time.Second)
+		clientClock.Advance(time.Second)
+	}
+	// the refresh of the last tick is done once the watch dog waits again.
+	clientClock.WaitForTimers(1)
+
+	expected := serverClock.Now().Add(3 * time.Second)
+	if deadline := mutex.ServerDeadline(); !deadline.Equal(expected) {
+		t.Fatalf("ServerDeadline is not equal,expected %v, got %v", expected, deadline)
+	}
+
+	ttl, err := mutex.TTL(ctx)
+	if err != nil {
+		t.Fatalf("TTL error:[%v]", err)
+	}
+	if ttl != 3*time.Second {
+		t.Fatalf("TTL is not equal,expected %v, got %v", 3*time.Second, ttl)
+	}
+}
@@ -0,0 +1,177 @@
+package redislocktest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/XdpCs/redis-lock/redislocktest"
+	"github.com/redis/go-redis/v9"
+)
+
+// waitClient is a RedisClient which can wait for replicas, such as Redis and FaultyClient.
+type waitClient interface {
+	redislock.RedisClient
+	Wait(ctx context.Context, numReplicas int, timeout time.Duration) *redis.IntCmd
+}
+
+// waitBackend is a testBackend which is a redislock.ReplicaWaiter.
+type waitBackend struct {
+	testBackend
+	client waitClient
+}
+
+func newWaitBackend(client waitClient) waitBackend {
+	return waitBackend{testBackend: testBackend{client: client}, client: client}
+}
+
+func (b waitBackend) EvalWait(ctx context.Context, script *redislock.Script, keys []string, args []interface{}, numReplicas int, timeout time.Duration) (interface{}, int64, error) {
+	reply, err := b.Eval(ctx, script, keys, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	acked, err := b.client.Wait(ctx, numReplicas, timeout).Result()
+	return reply, acked, err
+}
+
+func TestClient_WithWait(t *testing.T) {
+	key := "test"
+
+	// test cases
+	cases := []struct {
+		Name     string
+		Replicas int
+		Fault    *redislocktest.Fault // injected into WAIT.
+		Options  []redislock.ClientOption
+		Err      error
+	}{
+		{"Mutex", 0, nil, nil, redislock.ErrNotEnoughReplicas},
+		{"MutexWithFencing", 0, nil, []redislock.ClientOption{redislock.WithFencing()}, redislock.ErrNotEnoughReplicas},
+		{"WaitTimeout", 1, &redislocktest.Fault{Timeout: true}, nil, redislocktest.ErrTimeout},
+		{"Acknowledged", 1, nil, nil, nil},
+		{"AcknowledgedWithFencing", 1, nil, []redislock.ClientOption{redislock.WithFencing()}, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			rdb := redislocktest.NewRedis()
+			rdb.SetReplicas(c.Replicas)
+			faulty := redislocktest.NewFaultyClient(rdb)
+			if c.Fault != nil {
+				faulty.Inject(redislocktest.CommandWait, redislocktest.Always(*c.Fault))
+			}
+
+			client, err := redislock.NewClientWithBackend(newWaitBackend(faulty), append(c.Options, redislock.WithWait(1, 10*time.Millisecond))...)
+			if err != nil {
+				t.Fatalf("NewClient error:[%v]", err)
+			}
+
+			ctx := context.Background()
+			_, err = client.TryLock(ctx, key, 10*time.Second)
+			if !errors.Is(err, c.Err) {
+				t.Fatalf("TryLock is not equal,expected %v, got %v", c.Err, err)
+			}
+
+			// the lock is rolled back if the replicas did not acknowledge it.
+			expected := int64(1)
+			if c.Err != nil {
+				expected = 0
+			}
+			if exists, _ := rdb.Do("exists", key); exists != expected {
+				t.Fatalf("Exists is not equal,expected %v, got %v", expected, exists)
+			}
+		})
+	}
+}
+
+func TestClient_WithWait_Unsupported(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	client, err := redislock.NewClientWithBackend(testBackend{client: rdb}, redislock.WithWait(1, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+
+	if _, err = client.TryLock(context.Background(), "test", 10*time.Second); !redislock.IsWaitUnsupported(err) {
+		t.Fatalf("TryLock is not equal,expected %v, got %v", redislock.ErrWaitUnsupported, err)
+	}
+	if exists, _ := rdb.Do("exists", "test"); exists != int64(0) {
+		t.Fatalf("Exists is not equal,expected %v, got %v", 0, exists)
+	}
+}
+
+func TestClient_WithWait_RollbackAfterCancel(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	faulty := redislocktest.NewFaultyClient(rdb)
+	// WAIT outlives the caller, and so does the unlock rolling back the lock.
+	faulty.Inject(redislocktest.CommandWait, redislocktest.Always(redislocktest.Fault{Latency: time.Second}))
+	faulty.Inject(redislocktest.CommandUnlock, redislocktest.Always(redislocktest.Fault{Latency: 50 * time.Millisecond}))
+
+	client, err := redislock.NewClientWithBackend(newWaitBackend(faulty), redislock.WithWait(1, 0))
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err = client.TryLock(ctx, "test", 10*time.Second); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("TryLock is not equal,expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if exists, _ := rdb.Do("exists", "test"); exists != int64(0) {
+		t.Fatalf("Exists is not equal,expected %v, got %v", 0, exists)
+	}
+}
+
+func TestClient_WithWait_RWMutex(t *testing.T) {
+	key := "test"
+	rdb := redislocktest.NewRedis()
+	client, err := redislock.NewClientWithBackend(newWaitBackend(rdb), redislock.WithWait(1, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+	ctx := context.Background()
+
+	// the read lock is rolled back.
+	if _, err = client.TryReadLock(ctx, key, 10*time.Second, redislock.NewNoRetry()); !redislock.IsNotEnoughReplicas(err) {
+		t.Fatalf("TryReadLock is not equal,expected %v, got %v", redislock.ErrNotEnoughReplicas, err)
+	}
+	if exists, _ := rdb.Do("exists", key); exists != int64(0) {
+		t.Fatalf("Exists is not equal,expected %v, got %v", 0, exists)
+	}
+
+	rdb.SetReplicas(1)
+	rw, err := client.TryReadLock(ctx, key, 10*time.Second, redislock.NewNoRetry())
+	if err != nil {
+		t.Fatalf("TryReadLock error:[%v]", err)
+	}
+
+	// the upgrade is rolled back to the read lock.
+	rdb.SetReplicas(0)
+	if err = rw.Upgrade(ctx, redislock.NewNoRetry()); !redislock.IsNotEnoughReplicas(err) {
+		t.Fatalf("Upgrade is not equal,expected %v, got %v", redislock.ErrNotEnoughReplicas, err)
+	}
+	if rw.IsWriter() {
+		t.Fatalf("IsWriter is not equal,expected %v, got %v", false, true)
+	}
+
+	rdb.SetReplicas(1)
+	other, err := client.TryReadLock(ctx, key, 10*time.Second, redislock.NewNoRetry())
+	if err != nil {
+		t.Fatalf("TryReadLock error:[%v]", err)
+	}
+	if err = other.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+
+	if err = rw.Upgrade(ctx, redislock.NewNoRetry()); err != nil {
+		t.Fatalf("Upgrade error:[%v]", err)
+	}
+	if !rw.IsWriter() {
+		t.Fatalf("IsWriter is not equal,expected %v, got %v", true, false)
+	}
+	if err = rw.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+}
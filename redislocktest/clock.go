@@ -0,0 +1,185 @@
+package redislocktest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FakeClock is a clock that only moves when it is advanced,
+// it implements redislock.Clock and Clock, so one FakeClock can drive both the client and Redis.
+type FakeClock struct {
+	mu        sync.Mutex
+	cond      *sync.Cond // broadcasts when a timer is created.
+	now       time.Time
+	timers    []*waiter
+	deadlines []*waiter
+}
+
+// waiter is a pending timer or context deadline of FakeClock.
+type waiter struct {
+	at   time.Time
+	fire func(now time.Time)
+}
+
+// NewFakeClock creates a new FakeClock starting at now.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the current time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer returns a channel that receives the time once the clock is advanced by d,
+// and a function that stops the timer.
+func (c *FakeClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	ch := make(chan time.Time, 1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if d <= 0 {
+		ch <- c.now
+		return ch, func() bool { return false }
+	}
+
+	w := &waiter{at: c.now.Add(d), fire: func(now time.Time) { ch <- now }}
+	c.timers = append(c.timers, w)
+	c.cond.Broadcast()
+
+	return ch, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return remove(&c.timers, w)
+	}
+}
+
+// WithDeadline returns a copy of ctx that is done with context.DeadlineExceeded
+// once the clock reaches deadline.
+func (c *FakeClock) WithDeadline(ctx context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	if current, ok := ctx.Deadline(); ok && current.Before(deadline) {
+		return context.WithCancel(ctx)
+	}
+
+	dctx := &deadlineContext{Context: ctx, deadline: deadline, done: make(chan struct{})}
+	w := &waiter{at: deadline, fire: func(time.Time) { dctx.cancel(context.DeadlineExceeded) }}
+
+	c.mu.Lock()
+	expired := !c.now.Before(deadline)
+	if !expired {
+		c.deadlines = append(c.deadlines, w)
+	}
+	c.mu.Unlock()
+
+	if expired {
+		dctx.cancel(context.DeadlineExceeded)
+		return dctx, func() {}
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			dctx.cancel(ctx.Err())
+		case <-dctx.done:
+		}
+	}()
+
+	return dctx, func() {
+		c.mu.Lock()
+		remove(&c.deadlines, w)
+		c.mu.Unlock()
+		dctx.cancel(context.Canceled)
+	}
+}
+
+// Advance moves the clock forward by d, firing the timers and deadlines it passes in order.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+
+	var due []*waiter
+	c.timers, due = split(c.timers, now, due)
+	c.deadlines, due = split(c.deadlines, now, due)
+	c.mu.Unlock()
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].at.Before(due[j].at)
+	})
+	for _, w := range due {
+		w.fire(now)
+	}
+}
+
+// WaitForTimers blocks until at least n timers are pending,
+// it lets a test advance the clock only after the code under test started waiting.
+func (c *FakeClock) WaitForTimers(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// split moves the waiters that are due at now to due.
+func split(waiters []*waiter, now time.Time, due []*waiter) ([]*waiter, []*waiter) {
+	pending := waiters[:0]
+	for _, w := range waiters {
+		if now.Before(w.at) {
+			pending = append(pending, w)
+		} else {
+			due = append(due, w)
+		}
+	}
+	return pending, due
+}
+
+func remove(waiters *[]*waiter, w *waiter) bool {
+	for i, candidate := range *waiters {
+		if candidate == w {
+			*waiters = append((*waiters)[:i], (*waiters)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// deadlineContext is a context whose deadline is measured by FakeClock.
+type deadlineContext struct {
+	context.Context
+	deadline time.Time
+	done     chan struct{}
+	once     sync.Once
+	mu       sync.Mutex
+	err      error
+}
+
+func (c *deadlineContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+func (c *deadlineContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *deadlineContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *deadlineContext) cancel(err error) {
+	c.once.Do(func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		close(c.done)
+	})
+}
@@ -0,0 +1,83 @@
+package redislocktest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/XdpCs/redis-lock/redislocktest"
+)
+
+func TestFakeClock_NewTimer(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	clock := redislocktest.NewFakeClock(start)
+
+	timer, _ := clock.NewTimer(time.Second)
+	stopped, stop := clock.NewTimer(time.Second)
+	if !stop() {
+		t.Fatalf("stop is not equal,expected %v, got %v", true, false)
+	}
+
+	clock.Advance(999 * time.Millisecond)
+	select {
+	case <-timer:
+		t.Fatalf("timer fired before its time")
+	default:
+	}
+
+	clock.Advance(time.Millisecond)
+	select {
+	case now := <-timer:
+		if !now.Equal(start.Add(time.Second)) {
+			t.Fatalf("now is not equal,expected %v, got %v", start.Add(time.Second), now)
+		}
+	default:
+		t.Fatalf("timer did not fire")
+	}
+
+	select {
+	case <-stopped:
+		t.Fatalf("stopped timer fired")
+	default:
+	}
+}
+
+func TestFakeClock_WithDeadline(t *testing.T) {
+	clock := redislocktest.NewFakeClock(time.Unix(1700000000, 0))
+
+	ctx, cancel := clock.WithDeadline(context.Background(), clock.Now().Add(time.Second))
+	defer cancel()
+
+	clock.Advance(500 * time.Millisecond)
+	if err := ctx.Err(); err != nil {
+		t.Fatalf("ctx.Err is not equal,expected %v, got %v", nil, err)
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	<-ctx.Done()
+	if err := ctx.Err(); err != context.DeadlineExceeded {
+		t.Fatalf("ctx.Err is not equal,expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	expired, cancelExpired := clock.WithDeadline(context.Background(), clock.Now())
+	defer cancelExpired()
+	if err := expired.Err(); err != context.DeadlineExceeded {
+		t.Fatalf("expired.Err is not equal,expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestFakeClock_WaitForTimers(t *testing.T) {
+	clock := redislocktest.NewFakeClock(time.Unix(1700000000, 0))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		timer, stop := clock.NewTimer(time.Minute)
+		defer stop()
+		<-timer
+	}()
+
+	clock.WaitForTimers(1)
+	clock.Advance(time.Minute)
+	<-done
+}
@@ -0,0 +1,196 @@
+package redislocktest_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/XdpCs/redis-lock/election"
+	"github.com/XdpCs/redis-lock/redislocktest"
+)
+
+var errPartitioned = errors.New("network partitioned")
+
+func newElection(t *testing.T, rdb redislock.RedisClient, key, id string, elected, stopped *int32) *election.Election {
+	t.Helper()
+	client, err := redislock.NewClient(rdb)
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+
+	return election.New(client, key,
+		election.WithID(id),
+		election.WithExpiration(300*time.Millisecond),
+		election.WithRetryInterval(20*time.Millisecond),
+		election.WithOnElected(func() { atomic.AddInt32(elected, 1) }),
+		election.WithOnStopped(func() { atomic.AddInt32(stopped, 1) }),
+	)
+}
+
+func TestElection(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	partitioned := redislocktest.NewFaultyClient(rdb)
+	key := "testElection"
+
+	var electedOne, stoppedOne, electedTwo, stoppedTwo int32
+	candidateOne := newElection(t, partitioned, key, "one", &electedOne, &stoppedOne)
+	candidateTwo := newElection(t, rdb, key, "two", &electedTwo, &stoppedTwo)
+
+	ctx := context.Background()
+
+	if _, err := candidateTwo.Leader(ctx); !errors.Is(err, election.ErrNoLeader) {
+		t.Fatalf("Leader error is not equal,expected %v, got %v", election.ErrNoLeader, err)
+	}
+
+	if err := candidateOne.Campaign(ctx); err != nil {
+		t.Fatalf("candidateOne Campaign error:[%v]", err)
+	}
+
+	if !candidateOne.IsLeader() || atomic.LoadInt32(&electedOne) != 1 {
+		t.Fatalf("candidateOne is not leader after campaign")
+	}
+
+	t.Run("Leader", func(t *testing.T) {
+		id, err := candidateTwo.Leader(ctx)
+		if err != nil {
+			t.Fatalf("Leader error:[%v]", err)
+		}
+		if id != "one" {
+			t.Errorf("Leader is not equal,expected %v, got %v", "one", id)
+		}
+	})
+
+	t.Run("CampaignWhileLeaderAlive", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		defer cancel()
+
+		if err := candidateTwo.Campaign(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("candidateTwo Campaign error is not equal,expected %v, got %v", context.DeadlineExceeded, err)
+		}
+	})
+
+	t.Run("LeadershipLostOnPartition", func(t *testing.T) {
+		partitioned.Inject(redislocktest.CommandAll, redislocktest.Always(redislocktest.Fault{Err: errPartitioned}))
+
+		select {
+		case <-candidateOne.LeadershipLost():
+		case <-time.After(time.Second):
+			t.Fatalf("candidateOne does not lose the leadership on partition")
+		}
+
+		if candidateOne.IsLeader() {
+			t.Errorf("candidateOne is leader after the leadership is lost")
+		}
+
+		if atomic.LoadInt32(&stoppedOne) != 1 {
+			t.Errorf("onStopped of candidateOne is not called")
+		}
+	})
+
+	t.Run("NewLeaderAfterPartition", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+
+		if err := candidateTwo.Campaign(ctx); err != nil {
+			t.Fatalf("candidateTwo Campaign error:[%v]", err)
+		}
+
+		if !candidateTwo.IsLeader() || atomic.LoadInt32(&electedTwo) != 1 {
+			t.Errorf("candidateTwo is not leader after campaign")
+		}
+
+		if id, err := candidateTwo.Leader(ctx); err != nil || id != "two" {
+			t.Errorf("Leader is not equal,expected %v, got %v, error:[%v]", "two", id, err)
+		}
+	})
+
+	t.Run("Resign", func(t *testing.T) {
+		partitioned.Reset()
+
+		if err := candidateTwo.Resign(ctx); err != nil {
+			t.Fatalf("candidateTwo Resign error:[%v]", err)
+		}
+
+		if candidateTwo.IsLeader() {
+			t.Errorf("candidateTwo is leader after resign")
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		if err := candidateOne.Campaign(ctx); err != nil {
+			t.Fatalf("candidateOne Campaign error:[%v]", err)
+		}
+
+		if err := candidateOne.Resign(ctx); err != nil {
+			t.Fatalf("candidateOne Resign error:[%v]", err)
+		}
+	})
+}
+
+func TestElection_CampaignDoneWhileElecting(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	slow := redislocktest.NewFaultyClient(rdb)
+	// the acquire lasts beyond ctx of the campaign.
+	slow.Inject(redislocktest.CommandSetNX, redislocktest.Sequence(&redislocktest.Fault{Latency: 50 * time.Millisecond}))
+
+	var elected, stopped int32
+	candidate := newElection(t, slow, "testElection", "one", &elected, &stopped)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := candidate.Campaign(ctx); err != nil {
+		t.Fatalf("Campaign error:[%v]", err)
+	}
+
+	// the watch dog keeps the leadership beyond the expiration of 300ms.
+	time.Sleep(time.Second)
+	select {
+	case <-candidate.LeadershipLost():
+		t.Fatalf("LeadershipLost is closed after ctx of the campaign is done")
+	default:
+	}
+	if id, err := candidate.Leader(context.Background()); err != nil || id != "one" {
+		t.Fatalf("Leader is not equal,expected %v, got %v, error:[%v]", "one", id, err)
+	}
+
+	if err := candidate.Resign(context.Background()); err != nil {
+		t.Fatalf("Resign error:[%v]", err)
+	}
+}
+
+func TestElection_ConcurrentCampaign(t *testing.T) {
+	var elected, stopped int32
+	candidate := newElection(t, redislocktest.NewRedis(), "testElection", "one", &elected, &stopped)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- candidate.Campaign(ctx)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Campaign error:[%v]", err)
+		}
+	}
+
+	if !candidate.IsLeader() || atomic.LoadInt32(&elected) != 1 {
+		t.Fatalf("elected is not equal,expected %v, got %v", 1, atomic.LoadInt32(&elected))
+	}
+
+	if err := candidate.Resign(ctx); err != nil {
+		t.Fatalf("Resign error:[%v]", err)
+	}
+}
@@ -0,0 +1,351 @@
+package redislocktest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrTimeout is returned by FaultyClient for a call that times out or whose reply is dropped.
+var ErrTimeout = errors.New("redislocktest: i/o timeout")
+
+// commands of calls to FaultyClient, calls of redislock scripts are named by redislock.ScriptName,
+// such as CommandLock, CommandRefresh and CommandUnlock, and so are the calls of their functions.
+const (
+	CommandSetNX   = "setnx"
+	CommandGet     = "get"
+	CommandLock    = "lock"    // acquires a lock with fencing token.
+	CommandRefresh = "refresh" // refreshes a lock, used by Refresh and the watch dog.
+	CommandUnlock  = "unlock"
+	CommandWait    = "wait"
+	CommandLoad    = "load"  // loads of scripts by SCRIPT LOAD.
+	CommandEval    = "eval"  // calls of scripts that are not redislock scripts.
+	CommandFCall   = "fcall" // calls of functions that are not redislock scripts, such as the library version.
+	CommandAll     = ""      // matches calls of all commands in Inject.
+)
+
+// Call is a call to FaultyClient.
+type Call struct {
+	Command string
+	Keys    []string
+	Args    []interface{}
+}
+
+// Fault is the failure injected into a call.
+type Fault struct {
+	Latency time.Duration // delays the call, the delay ends early with the error of ctx if ctx is done.
+	Err     error         // returned instead of sending the command.
+	Timeout bool          // the command is not sent, ErrTimeout is returned.
+	Drop    bool          // the command is sent, but its reply is lost, ErrTimeout is returned.
+}
+
+// Injector decides the fault of a call, nil means no fault.
+type Injector func(call Call, random *rand.Rand) *Fault
+
+// Always injects fault into every call.
+func Always(fault Fault) Injector {
+	return func(Call, *rand.Rand) *Fault {
+		return &fault
+	}
+}
+
+// Probability injects fault into a call with probability p.
+func Probability(p float64, fault Fault) Injector {
+	return func(_ Call, random *rand.Rand) *Fault {
+		if random.Float64() < p {
+			return &fault
+		}
+		return nil
+	}
+}
+
+// Sequence injects faults in order, the n-th call gets the n-th fault,
+// nil faults and calls after the sequence pass through.
+func Sequence(faults ...*Fault) Injector {
+	var mu sync.Mutex
+	next := 0
+	return func(Call, *rand.Rand) *Fault {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if next >= len(faults) {
+			return nil
+		}
+		fault := faults[next]
+		next++
+		return fault
+	}
+}
+
+type rule struct {
+	command  string
+	injector Injector
+}
+
+// FaultyClient is a redislock.RedisClient that injects faults into the calls to the client it wraps.
+// The EVALSHA or EVAL retrying an EVALSHA answered with NOSCRIPT, see redislock.IsNoScriptRetry,
+// is part of the same call, it does not consult the injectors again.
+type FaultyClient struct {
+	client redislock.RedisClient
+	clock  redislock.Clock // times the injected latency.
+
+	mu     sync.Mutex
+	random *rand.Rand
+	rules  []rule
+}
+
+// NewFaultyClient creates a new FaultyClient wrapping client, it injects no faults until Inject is called.
+func NewFaultyClient(client redislock.RedisClient, options ...FaultyOption) *FaultyClient {
+	f := &FaultyClient{
+		client: client,
+		clock:  systemClock{},
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	for _, option := range options {
+		option(f)
+	}
+
+	return f
+}
+
+type FaultyOption func(client *FaultyClient)
+
+// WithSeed seeds the random source of Probability, so the injected faults are reproducible.
+func WithSeed(seed int64) FaultyOption {
+	return func(client *FaultyClient) {
+		client.random = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithFaultyClock sets the clock timing the injected latency, default is the system clock,
+// it should be the clock of the redislock client.
+func WithFaultyClock(clock redislock.Clock) FaultyOption {
+	return func(client *FaultyClient) {
+		client.clock = clock
+	}
+}
+
+// Inject adds injector for calls of command, CommandAll matches all calls,
+// injectors are consulted in the order they are added and the first fault is injected.
+func (f *FaultyClient) Inject(command string, injector Injector) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, rule{command: command, injector: injector})
+}
+
+// Reset removes all injectors.
+func (f *FaultyClient) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = nil
+}
+
+// SetNX sets key to value if key does not exist.
+func (f *FaultyClient) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	err := f.inject(ctx, Call{Command: CommandSetNX, Keys: []string{key}, Args: []interface{}{value, expiration}}, func() error {
+		return f.client.SetNX(ctx, key, value, expiration).Err()
+	})
+	if err != nil {
+		return redis.NewBoolResult(false, err)
+	}
+	return f.client.SetNX(ctx, key, value, expiration)
+}
+
+// Get returns the value of key.
+func (f *FaultyClient) Get(ctx context.Context, key string) *redis.StringCmd {
+	err := f.inject(ctx, Call{Command: CommandGet, Keys: []string{key}}, func() error {
+		return f.client.Get(ctx, key).Err()
+	})
+	if err != nil {
+		return redis.NewStringResult("", err)
+	}
+	return f.client.Get(ctx, key)
+}
+
+// Eval runs script.
+func (f *FaultyClient) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	if redislock.IsNoScriptRetry(ctx) {
+		return f.client.Eval(ctx, script, keys, args...)
+	}
+
+	return f.eval(ctx, sha1Hex(script), keys, args, func() *redis.Cmd {
+		return f.client.Eval(ctx, script, keys, args...)
+	})
+}
+
+// EvalSha runs the script loaded as sha1.
+func (f *FaultyClient) EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd {
+	if redislock.IsNoScriptRetry(ctx) {
+		return f.client.EvalSha(ctx, sha1, keys, args...)
+	}
+
+	return f.eval(ctx, sha1, keys, args, func() *redis.Cmd {
+		return f.client.EvalSha(ctx, sha1, keys, args...)
+	})
+}
+
+// EvalRO runs the read-only script.
+func (f *FaultyClient) EvalRO(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	if redislock.IsNoScriptRetry(ctx) {
+		return f.client.EvalRO(ctx, script, keys, args...)
+	}
+
+	return f.eval(ctx, sha1Hex(script), keys, args, func() *redis.Cmd {
+		return f.client.EvalRO(ctx, script, keys, args...)
+	})
+}
+
+// EvalShaRO runs the read-only script loaded as sha1.
+func (f *FaultyClient) EvalShaRO(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd {
+	if redislock.IsNoScriptRetry(ctx) {
+		return f.client.EvalShaRO(ctx, sha1, keys, args...)
+	}
+
+	return f.eval(ctx, sha1, keys, args, func() *redis.Cmd {
+		return f.client.EvalShaRO(ctx, sha1, keys, args...)
+	})
+}
+
+// functionCaller is implemented by the clients which can call functions, such as Redis.
+type functionCaller interface {
+	FCall(ctx context.Context, function string, keys []string, args ...interface{}) *redis.Cmd
+	FunctionLoadReplace(ctx context.Context, code string) *redis.StringCmd
+}
+
+// FCall calls function, it answers like redis before 7 if the wrapped client cannot call functions.
+func (f *FaultyClient) FCall(ctx context.Context, function string, keys []string, args ...interface{}) *redis.Cmd {
+	caller, ok := f.client.(functionCaller)
+	if !ok {
+		return redis.NewCmdResult(nil, replyError("ERR unknown command 'fcall'"))
+	}
+
+	command := CommandFCall
+	prefix := fmt.Sprintf("%s_v%d_", redislock.LibraryName, redislock.LibraryVersion)
+	if strings.HasPrefix(function, prefix) {
+		command = strings.TrimPrefix(function, prefix)
+	}
+
+	err := f.inject(ctx, Call{Command: command, Keys: keys, Args: args}, func() error {
+		return caller.FCall(ctx, function, keys, args...).Err()
+	})
+	if err != nil {
+		return redis.NewCmdResult(nil, err)
+	}
+	return caller.FCall(ctx, function, keys, args...)
+}
+
+// replicaWaiter is implemented by the clients which can wait for replicas, such as Redis.
+type replicaWaiter interface {
+	Wait(ctx context.Context, numReplicas int, timeout time.Duration) *redis.IntCmd
+}
+
+// Wait waits for numReplicas replicas to acknowledge the writes, it answers like a redis
+// without the command if the wrapped client cannot wait.
+func (f *FaultyClient) Wait(ctx context.Context, numReplicas int, timeout time.Duration) *redis.IntCmd {
+	waiter, ok := f.client.(replicaWaiter)
+	if !ok {
+		return redis.NewIntResult(0, replyError("ERR unknown command 'wait'"))
+	}
+
+	err := f.inject(ctx, Call{Command: CommandWait, Args: []interface{}{numReplicas, timeout}}, func() error {
+		return waiter.Wait(ctx, numReplicas, timeout).Err()
+	})
+	if err != nil {
+		return redis.NewIntResult(0, err)
+	}
+	return waiter.Wait(ctx, numReplicas, timeout)
+}
+
+// FunctionLoadReplace passes through without faults.
+func (f *FaultyClient) FunctionLoadReplace(ctx context.Context, code string) *redis.StringCmd {
+	caller, ok := f.client.(functionCaller)
+	if !ok {
+		return redis.NewStringResult("", replyError("ERR unknown command 'function'"))
+	}
+	return caller.FunctionLoadReplace(ctx, code)
+}
+
+// ScriptExists passes through without faults.
+func (f *FaultyClient) ScriptExists(ctx context.Context, hashes ...string) *redis.BoolSliceCmd {
+	return f.client.ScriptExists(ctx, hashes...)
+}
+
+// ScriptLoad loads script.
+func (f *FaultyClient) ScriptLoad(ctx context.Context, script string) *redis.StringCmd {
+	err := f.inject(ctx, Call{Command: CommandLoad, Args: []interface{}{script}}, func() error {
+		return f.client.ScriptLoad(ctx, script).Err()
+	})
+	if err != nil {
+		return redis.NewStringResult("", err)
+	}
+	return f.client.ScriptLoad(ctx, script)
+}
+
+func (f *FaultyClient) eval(ctx context.Context, sha1 string, keys []string, args []interface{}, run func() *redis.Cmd) *redis.Cmd {
+	command := redislock.ScriptName(sha1)
+	if command == "" {
+		command = CommandEval
+	}
+
+	err := f.inject(ctx, Call{Command: command, Keys: keys, Args: args}, func() error {
+		return run().Err()
+	})
+	if err != nil {
+		return redis.NewCmdResult(nil, err)
+	}
+	return run()
+}
+
+// inject injects the fault of call, it returns nil if the call is to be sent as usual,
+// send sends the command of call when its reply is dropped.
+func (f *FaultyClient) inject(ctx context.Context, call Call, send func() error) error {
+	fault := f.fault(call)
+	if fault == nil {
+		return nil
+	}
+
+	if fault.Latency > 0 {
+		timer, stop := f.clock.NewTimer(fault.Latency)
+		defer stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer:
+		}
+	}
+
+	switch {
+	case fault.Err != nil:
+		return fault.Err
+	case fault.Timeout:
+		return ErrTimeout
+	case fault.Drop:
+		_ = send()
+		return ErrTimeout
+	}
+	return nil
+}
+
+func (f *FaultyClient) fault(call Call) *Fault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, r := range f.rules {
+		if r.command != CommandAll && r.command != call.Command {
+			continue
+		}
+		if fault := r.injector(call, f.random); fault != nil {
+			return fault
+		}
+	}
+	return nil
+}
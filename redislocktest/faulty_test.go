@@ -0,0 +1,229 @@
+package redislocktest_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/XdpCs/redis-lock/redislocktest"
+)
+
+func TestFaultyClient_Inject(t *testing.T) {
+	errInjected := errors.New("injected")
+
+	// test cases
+	tests := []struct {
+		Name     string
+		Command  string
+		Fencing  bool
+		Fault    redislocktest.Fault
+		Expected error
+		Held     bool // whether the lock is held after the failed call.
+	}{
+		{"SetNXError", redislocktest.CommandSetNX, false, redislocktest.Fault{Err: errInjected}, errInjected, false},
+		{"SetNXTimeout", redislocktest.CommandSetNX, false, redislocktest.Fault{Timeout: true}, redislocktest.ErrTimeout, false},
+		{"SetNXDrop", redislocktest.CommandSetNX, false, redislocktest.Fault{Drop: true}, redislocktest.ErrTimeout, true},
+		{"LockDrop", redislocktest.CommandLock, true, redislocktest.Fault{Drop: true}, redislocktest.ErrTimeout, true},
+		{"AllTimeout", redislocktest.CommandAll, true, redislocktest.Fault{Timeout: true}, redislocktest.ErrTimeout, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			rdb := redislocktest.NewRedis()
+			faulty := redislocktest.NewFaultyClient(rdb)
+			options := []redislock.ClientOption{redislock.WithCipherKey("1118")}
+			if tc.Fencing {
+				options = append(options, redislock.WithFencing())
+			}
+			client, err := redislock.NewClient(faulty, options...)
+			if err != nil {
+				t.Fatalf("NewClient error:[%v]", err)
+			}
+
+			ctx := context.Background()
+			// loads the scripts, so the injected call is not answered with NOSCRIPT.
+			if err = client.Warmup(ctx); err != nil {
+				t.Fatalf("Warmup error:[%v]", err)
+			}
+			faulty.Inject(tc.Command, redislocktest.Sequence(&tc.Fault))
+
+			if _, err = client.TryLock(ctx, "test", time.Minute); !errors.Is(err, tc.Expected) {
+				t.Fatalf("TryLock is not equal,expected %v, got %v", tc.Expected, err)
+			}
+
+			exists, err := rdb.Do("exists", "test")
+			if err != nil {
+				t.Fatalf("Do error:[%v]", err)
+			}
+			if held := exists == int64(1); held != tc.Held {
+				t.Fatalf("Held is not equal,expected %v, got %v", tc.Held, held)
+			}
+		})
+	}
+}
+
+func TestFaultyClient_WatchDogLost(t *testing.T) {
+	clock := redislocktest.NewFakeClock(time.Unix(1700000000, 0))
+	faulty := redislocktest.NewFaultyClient(redislocktest.NewRedis(redislocktest.WithClock(clock)))
+	client, err := redislock.NewClient(faulty, redislock.WithClock(clock))
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+
+	ctx := context.Background()
+	mutex, err := client.TryLockWithWatchDog(ctx, "test", redislock.NewWatchDog(3*time.Second))
+	if err != nil {
+		t.Fatalf("TryLockWithWatchDog error:[%v]", err)
+	}
+
+	// the first refresh succeeds, the second one times out.
+	faulty.Inject(redislocktest.CommandRefresh, redislocktest.Sequence(nil, &redislocktest.Fault{Timeout: true}))
+	for i := 0; i < 2; i++ {
+		clock.WaitForTimers(1)
+		clock.Advance(time.Second)
+	}
+
+	<-mutex.Lost()
+
+	errInjected := errors.New("injected")
+	faulty.Inject(redislocktest.CommandUnlock, redislocktest.Sequence(&redislocktest.Fault{Err: errInjected}))
+	if err = mutex.Unlock(ctx); !errors.Is(err, errInjected) {
+		t.Fatalf("Unlock is not equal,expected %v, got %v", errInjected, err)
+	}
+
+	// the timed out refresh is not sent, so the lock is still held until it expires.
+	if err = mutex.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+}
+
+func TestFaultyClient_NoScript(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	faulty := redislocktest.NewFaultyClient(rdb)
+	client, err := redislock.NewDefaultClient(faulty)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+
+	ctx := context.Background()
+	mutex, err := client.TryLock(ctx, "test", time.Minute)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+
+	// the EVALSHA answered with NOSCRIPT and its retry after the reload are one call.
+	rdb.ScriptFlush()
+	errInjected := errors.New("injected")
+	faulty.Inject(redislocktest.CommandRefresh, redislocktest.Sequence(nil, &redislocktest.Fault{Err: errInjected}))
+	if err = mutex.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh error:[%v]", err)
+	}
+	if err = mutex.Refresh(ctx); !errors.Is(err, errInjected) {
+		t.Fatalf("Refresh is not equal,expected %v, got %v", errInjected, err)
+	}
+}
+
+func TestFaultyClient_NoScriptNewCall(t *testing.T) {
+	faulty := redislocktest.NewFaultyClient(redislocktest.NewRedis())
+	ctx := context.Background()
+	hash := sha1.Sum([]byte("return 1"))
+	sha := hex.EncodeToString(hash[:])
+
+	// a NOSCRIPT reply does not let the next call of the script skip the injectors,
+	// only the retry marked by the client is part of the same call.
+	if err := faulty.EvalSha(ctx, sha, nil).Err(); err == nil || !strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		t.Fatalf("EvalSha is not equal,expected NOSCRIPT, got %v", err)
+	}
+
+	errInjected := errors.New("injected")
+	faulty.Inject(redislocktest.CommandEval, redislocktest.Always(redislocktest.Fault{Err: errInjected}))
+	if err := faulty.EvalSha(ctx, sha, nil).Err(); !errors.Is(err, errInjected) {
+		t.Fatalf("EvalSha is not equal,expected %v, got %v", errInjected, err)
+	}
+	if err := faulty.Eval(ctx, "return 1", nil).Err(); !errors.Is(err, errInjected) {
+		t.Fatalf("Eval is not equal,expected %v, got %v", errInjected, err)
+	}
+}
+
+func TestFaultyClient_Probability(t *testing.T) {
+	ctx := context.Background()
+
+	outcomes := func() []bool {
+		faulty := redislocktest.NewFaultyClient(redislocktest.NewRedis(), redislocktest.WithSeed(1118))
+		faulty.Inject(redislocktest.CommandSetNX, redislocktest.Probability(0.5, redislocktest.Fault{Timeout: true}))
+
+		outcomes := make([]bool, 100)
+		for i := range outcomes {
+			outcomes[i] = faulty.SetNX(ctx, strconv.Itoa(i), "value", time.Minute).Err() == nil
+		}
+		return outcomes
+	}
+
+	first, second := outcomes(), outcomes()
+	succeeded := 0
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("outcomes are not equal at %d,expected %v, got %v", i, first[i], second[i])
+		}
+		if first[i] {
+			succeeded++
+		}
+	}
+
+	if succeeded == 0 || succeeded == len(first) {
+		t.Fatalf("succeeded is not between 0 and %d, got %d", len(first), succeeded)
+	}
+}
+
+func TestFaultyClient_Latency(t *testing.T) {
+	faulty := redislocktest.NewFaultyClient(redislocktest.NewRedis())
+	faulty.Inject(redislocktest.CommandAll, redislocktest.Always(redislocktest.Fault{Latency: time.Hour}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := faulty.SetNX(ctx, "test", "value", time.Minute).Err(); err != context.DeadlineExceeded {
+		t.Fatalf("SetNX is not equal,expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestFaultyClient_LatencyClock(t *testing.T) {
+	clock := redislocktest.NewFakeClock(time.Unix(1700000000, 0))
+	faulty := redislocktest.NewFaultyClient(redislocktest.NewRedis(redislocktest.WithClock(clock)), redislocktest.WithFaultyClock(clock))
+	faulty.Inject(redislocktest.CommandAll, redislocktest.Always(redislocktest.Fault{Latency: time.Hour}))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- faulty.SetNX(context.Background(), "test", "value", time.Minute).Err()
+	}()
+
+	// the latency ends once the clock passes it.
+	clock.WaitForTimers(1)
+	clock.Advance(time.Hour)
+
+	if err := <-done; err != nil {
+		t.Fatalf("SetNX error:[%v]", err)
+	}
+}
+
+func TestFaultyClient_FCall(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	client, err := redislock.NewClient(redislocktest.NewFaultyClient(rdb), redislock.WithFunctions(), redislock.WithFencing())
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+
+	// the functions of the wrapped client are called through FaultyClient.
+	if _, err = client.TryLock(context.Background(), "test", time.Minute); err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+	if reply, err := rdb.Do("fcall", "redislock_version", "0"); err != nil || reply != int64(redislock.LibraryVersion) {
+		t.Fatalf("version is not equal,expected %v, got %v, error:[%v]", redislock.LibraryVersion, reply, err)
+	}
+}
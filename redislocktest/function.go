@@ -0,0 +1,226 @@
+package redislocktest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// library is a function library loaded by FUNCTION LOAD.
+type library struct {
+	code      string
+	functions []string
+}
+
+// FCall calls function of a loaded library.
+func (r *Redis) FCall(ctx context.Context, function string, keys []string, args ...interface{}) *redis.Cmd {
+	argv := make([]string, 0, 3+len(keys)+len(args))
+	argv = append(argv, "fcall", function, strconv.Itoa(len(keys)))
+	argv = append(argv, keys...)
+	for _, arg := range args {
+		argv = append(argv, toString(arg))
+	}
+
+	reply, err := r.Do(argv...)
+	if err == nil && reply == nil {
+		err = redis.Nil
+	}
+	return redis.NewCmdResult(reply, err)
+}
+
+// FunctionLoad loads the library of code.
+func (r *Redis) FunctionLoad(ctx context.Context, code string) *redis.StringCmd {
+	reply, err := r.Do("function", "load", code)
+	name, _ := reply.(string)
+	return redis.NewStringResult(name, err)
+}
+
+// FunctionLoadReplace loads the library of code, replacing the library of the same name.
+func (r *Redis) FunctionLoadReplace(ctx context.Context, code string) *redis.StringCmd {
+	reply, err := r.Do("function", "load", "replace", code)
+	name, _ := reply.(string)
+	return redis.NewStringResult(name, err)
+}
+
+func cmdFunction(r *Redis, args []string) (interface{}, error) {
+	switch strings.ToLower(args[0]) {
+	case "load":
+		replace := len(args) == 3 && strings.ToLower(args[1]) == "replace"
+		if len(args) != 2 && !replace {
+			return nil, errWrongArgsNum
+		}
+		return r.loadLibrary(args[len(args)-1], replace)
+	case "delete":
+		if len(args) != 2 {
+			return nil, errWrongArgsNum
+		}
+		if _, ok := r.libraries[args[1]]; !ok {
+			return nil, replyError("ERR Library not found")
+		}
+		delete(r.libraries, args[1])
+		return status("OK"), nil
+	case "flush":
+		r.libraries = make(map[string]*library)
+		return status("OK"), nil
+	default:
+		return nil, replyError(fmt.Sprintf("ERR unknown subcommand '%s'", args[0]))
+	}
+}
+
+func cmdFCall(r *Redis, args []string) (interface{}, error) {
+	code, ok := r.functionCode(args[0])
+	if !ok {
+		return nil, replyError("ERR Function not found")
+	}
+
+	numKeys, err := strconv.Atoi(args[1])
+	if err != nil || numKeys < 0 {
+		return nil, replyError("ERR Bad number of keys provided")
+	}
+	if numKeys > len(args)-2 {
+		return nil, replyError("ERR Number of keys can't be greater than number of args")
+	}
+
+	L := r.newState()
+	defer L.Close()
+
+	functions, err := registerFunctions(L, code)
+	if err != nil {
+		return nil, err
+	}
+
+	err = L.CallByParam(lua.P{Fn: functions[args[0]], NRet: 1, Protect: true},
+		stringsTable(L, args[2:2+numKeys]), stringsTable(L, args[2+numKeys:]))
+	if err != nil {
+		return nil, scriptError(err)
+	}
+	return fromLua(L.Get(-1))
+}
+
+// loadLibrary loads the library of code, r.mu must be held.
+func (r *Redis) loadLibrary(code string, replace bool) (interface{}, error) {
+	name, err := libraryName(code)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := r.libraries[name]; ok && !replace {
+		return nil, replyError(fmt.Sprintf("ERR Library '%s' already exists", name))
+	}
+
+	L := r.newState()
+	defer L.Close()
+
+	// redis.call is not available while the library is loaded.
+	redisTable := L.GetGlobal("redis").(*lua.LTable)
+	for _, field := range []string{"call", "pcall"} {
+		field := field
+		L.SetField(redisTable, field, L.NewFunction(func(L *lua.LState) int {
+			L.RaiseError("attempt to call field '%s' (a nil value)", field)
+			return 0
+		}))
+	}
+
+	functions, err := registerFunctions(L, code)
+	if err != nil {
+		return nil, err
+	}
+	if len(functions) == 0 {
+		return nil, replyError("ERR No functions registered")
+	}
+
+	lib := &library{code: code}
+	for function := range functions {
+		lib.functions = append(lib.functions, function)
+	}
+	sort.Strings(lib.functions)
+	for _, function := range lib.functions {
+		if other, ok := r.functionLibrary(function); ok && other != name {
+			return nil, replyError(fmt.Sprintf("ERR Function %s already exists", function))
+		}
+	}
+	r.libraries[name] = lib
+	return name, nil
+}
+
+// functionCode returns the code of the library of function, r.mu must be held.
+func (r *Redis) functionCode(function string) (string, bool) {
+	name, ok := r.functionLibrary(function)
+	if !ok {
+		return "", false
+	}
+	return r.libraries[name].code, true
+}
+
+// functionLibrary returns the name of the library of function, r.mu must be held.
+func (r *Redis) functionLibrary(function string) (string, bool) {
+	for name, lib := range r.libraries {
+		for _, f := range lib.functions {
+			if f == function {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// libraryName returns the name in the "#!lua name=<name>" header of code.
+func libraryName(code string) (string, error) {
+	header := code
+	if i := strings.IndexByte(code, '\n'); i >= 0 {
+		header = code[:i]
+	}
+
+	fields := strings.Fields(header)
+	if len(fields) == 0 || fields[0] != "#!lua" {
+		return "", replyError("ERR Missing library metadata")
+	}
+	for _, field := range fields[1:] {
+		if name := strings.TrimPrefix(field, "name="); name != field && name != "" {
+			return name, nil
+		}
+	}
+	return "", replyError("ERR Library name was not given")
+}
+
+// registerFunctions runs the library code in L and returns the functions it registers by name.
+func registerFunctions(L *lua.LState, code string) (map[string]*lua.LFunction, error) {
+	functions := make(map[string]*lua.LFunction)
+	redisTable := L.GetGlobal("redis").(*lua.LTable)
+	L.SetField(redisTable, "register_function", L.NewFunction(func(L *lua.LState) int {
+		var name lua.LValue
+		var callback lua.LValue
+		if t, ok := L.Get(1).(*lua.LTable); ok {
+			name, callback = t.RawGetString("function_name"), t.RawGetString("callback")
+		} else {
+			name, callback = L.Get(1), L.Get(2)
+		}
+
+		fn, ok := callback.(*lua.LFunction)
+		if name.Type() != lua.LTString || !ok {
+			L.RaiseError("wrong arguments to redis.register_function")
+			return 0
+		}
+		if _, ok := functions[name.String()]; ok {
+			L.RaiseError("Function already exists in the library")
+			return 0
+		}
+		functions[name.String()] = fn
+		return 0
+	}))
+
+	// the header is not Lua, it is blanked so line numbers do not change.
+	if i := strings.IndexByte(code, '\n'); i >= 0 {
+		code = code[i:]
+	} else {
+		code = ""
+	}
+	if err := L.DoString(code); err != nil {
+		return nil, scriptError(err)
+	}
+	return functions, nil
+}
@@ -0,0 +1,74 @@
+package redislocktest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/XdpCs/redis-lock/redislocktest"
+)
+
+func TestIdempotency_Do_LockLost(t *testing.T) {
+	client, clock := newFakeClockClient(t)
+	idempotency := client.NewIdempotency()
+	ctx := context.Background()
+
+	handled := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := idempotency.Do(ctx, "test", func(ctx context.Context) (*redislock.Response, error) {
+			close(handled)
+			// the ctx of handle is done once the lock expires.
+			<-ctx.Done()
+			return &redislock.Response{Status: http.StatusOK}, nil
+		})
+		done <- err
+	}()
+
+	<-handled
+	clock.WaitForTimers(1)
+	clock.Advance(redislock.DefaultExpiration)
+
+	if err := <-done; !redislock.IsMutexNotHeld(err) {
+		t.Fatalf("Do error is not equal,expected %v, got %v", redislock.ErrMutexNotHeld, err)
+	}
+
+	// the response of the request which lost the lock is not stored, so the key is handled again.
+	response, replayed, err := idempotency.Do(ctx, "test", func(ctx context.Context) (*redislock.Response, error) {
+		return &redislock.Response{Status: http.StatusCreated}, nil
+	})
+	if err != nil {
+		t.Fatalf("Do error:[%v]", err)
+	}
+	if replayed || response.Status != http.StatusCreated {
+		t.Fatalf("response is not equal,expected %v, got %v, replayed %v", http.StatusCreated, response.Status, replayed)
+	}
+}
+
+func TestIdempotency_Do_UnlockAfterCancel(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	faulty := redislocktest.NewFaultyClient(rdb)
+	// the unlock fails at once with a done ctx.
+	faulty.Inject(redislocktest.CommandUnlock, redislocktest.Always(redislocktest.Fault{Latency: time.Millisecond}))
+	client, err := redislock.NewClient(faulty)
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, _, err = client.NewIdempotency().Do(ctx, "test", func(context.Context) (*redislock.Response, error) {
+		cancel()
+		return nil, errors.New("cancelled")
+	})
+	if err == nil {
+		t.Fatalf("Do is not equal,expected an error, got nil")
+	}
+
+	// the lock is released, so a retried request does not wait for its expiration.
+	if exists, _ := rdb.Do("exists", "{test}:lock"); exists != int64(0) {
+		t.Fatalf("Exists is not equal,expected %v, got %v", 0, exists)
+	}
+}
@@ -0,0 +1,77 @@
+package redislocktest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+)
+
+func TestCountDownLatch_Await_NotSet(t *testing.T) {
+	client, _, clock := newBarrierClient(t)
+	ctx := context.Background()
+	latch := client.NewCountDownLatch("test", 3*time.Second)
+
+	// a latch whose count is not set is not open.
+	if err := latch.Await(ctx); !redislock.IsLatchNotSet(err) {
+		t.Fatalf("Await is not equal,expected %v, got %v", redislock.ErrLatchNotSet, err)
+	}
+
+	if ok, err := latch.TrySetCount(ctx, 1); err != nil || !ok {
+		t.Fatalf("TrySetCount error:[%v], ok:[%v]", err, ok)
+	}
+
+	// nobody waits, so the latch expires before it is counted down.
+	clock.Advance(4 * time.Second)
+	if err := latch.CountDown(ctx); !redislock.IsLatchNotSet(err) {
+		t.Fatalf("CountDown is not equal,expected %v, got %v", redislock.ErrLatchNotSet, err)
+	}
+	if err := latch.Await(ctx); !redislock.IsLatchNotSet(err) {
+		t.Fatalf("Await is not equal,expected %v, got %v", redislock.ErrLatchNotSet, err)
+	}
+	if _, err := latch.Count(ctx); !redislock.IsLatchNotSet(err) {
+		t.Fatalf("Count is not equal,expected %v, got %v", redislock.ErrLatchNotSet, err)
+	}
+}
+
+func TestCountDownLatch_Await_Waiting(t *testing.T) {
+	client, _, clock := newBarrierClient(t)
+	ctx := context.Background()
+	latch := client.NewCountDownLatch("test", 3*time.Second)
+
+	if ok, err := latch.TrySetCount(ctx, 1); err != nil || !ok {
+		t.Fatalf("TrySetCount error:[%v], ok:[%v]", err, ok)
+	}
+
+	awaited := make(chan error, 1)
+	go func() {
+		awaited <- latch.Await(ctx)
+	}()
+
+	// the waiter keeps the latch far beyond its expiration while the setup is slow.
+	for i := 0; i < 10; i++ {
+		clock.WaitForTimers(1)
+		clock.Advance(time.Second)
+	}
+	clock.WaitForTimers(1)
+
+	if err := latch.CountDown(ctx); err != nil {
+		t.Fatalf("CountDown error:[%v]", err)
+	}
+	clock.Advance(time.Second)
+	if err := <-awaited; err != nil {
+		t.Fatalf("Await error:[%v]", err)
+	}
+
+	// the open latch is kept, later waiters pass and it can be set again.
+	if err := latch.Await(ctx); err != nil {
+		t.Fatalf("Await error:[%v]", err)
+	}
+	if count, err := latch.Count(ctx); err != nil || count != 0 {
+		t.Fatalf("Count is not equal,expected %v, got %v, error:[%v]", 0, count, err)
+	}
+	if ok, err := latch.TrySetCount(ctx, 2); err != nil || !ok {
+		t.Fatalf("TrySetCount error:[%v], ok:[%v]", err, ok)
+	}
+}
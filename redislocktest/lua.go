@@ -0,0 +1,198 @@
+package redislocktest
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// runScript runs script with keys and argv like EVAL, r.mu must be held.
+func (r *Redis) runScript(script string, keys, argv []string) (interface{}, error) {
+	L := r.newState()
+	defer L.Close()
+
+	L.SetGlobal("KEYS", stringsTable(L, keys))
+	L.SetGlobal("ARGV", stringsTable(L, argv))
+
+	if err := L.DoString(script); err != nil {
+		return nil, scriptError(err)
+	}
+
+	if L.GetTop() == 0 {
+		return nil, nil
+	}
+	return fromLua(L.Get(-1))
+}
+
+// newState creates a Lua state with the libraries and the redis table available to scripts.
+func (r *Redis) newState() *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	redisTable := L.NewTable()
+	L.SetField(redisTable, "call", L.NewFunction(func(L *lua.LState) int {
+		reply, err := r.doFromScript(commandArgs(L))
+		if err != nil {
+			L.RaiseError("%s", err.Error())
+			return 0
+		}
+		L.Push(toLua(L, reply))
+		return 1
+	}))
+	L.SetField(redisTable, "pcall", L.NewFunction(func(L *lua.LState) int {
+		reply, err := r.doFromScript(commandArgs(L))
+		if err != nil {
+			L.Push(errorTable(L, err.Error()))
+			return 1
+		}
+		L.Push(toLua(L, reply))
+		return 1
+	}))
+	L.SetField(redisTable, "error_reply", L.NewFunction(func(L *lua.LState) int {
+		L.Push(errorTable(L, L.CheckString(1)))
+		return 1
+	}))
+	L.SetField(redisTable, "status_reply", L.NewFunction(func(L *lua.LState) int {
+		t := L.NewTable()
+		L.SetField(t, "ok", lua.LString(L.CheckString(1)))
+		L.Push(t)
+		return 1
+	}))
+	L.SetGlobal("redis", redisTable)
+
+	return L
+}
+
+// scriptError converts an error raised by a script to an error reply.
+func scriptError(err error) error {
+	var apiErr *lua.ApiError
+	if errors.As(err, &apiErr) {
+		return replyError("ERR Error running script: " + apiErr.Object.String())
+	}
+	return err
+}
+
+// doFromScript runs a command called by a script, scripts cannot run scripts.
+func (r *Redis) doFromScript(args []string) (interface{}, error) {
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "eval", "evalsha", "script", "fcall", "function", "wait":
+			return nil, replyError("ERR This Redis command is not allowed from script")
+		}
+	}
+	return r.do(args)
+}
+
+// commandArgs returns the arguments of redis.call.
+func commandArgs(L *lua.LState) []string {
+	args := make([]string, L.GetTop())
+	for i := range args {
+		switch v := L.Get(i + 1).(type) {
+		case lua.LString:
+			args[i] = string(v)
+		case lua.LNumber:
+			args[i] = formatNumber(float64(v))
+		default:
+			L.RaiseError("Lua redis() command arguments must be strings or integers")
+		}
+	}
+	return args
+}
+
+func formatNumber(f float64) string {
+	if f == float64(int64(f)) {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func stringsTable(L *lua.LState, values []string) *lua.LTable {
+	t := L.CreateTable(len(values), 0)
+	for _, v := range values {
+		t.Append(lua.LString(v))
+	}
+	return t
+}
+
+func errorTable(L *lua.LState, message string) *lua.LTable {
+	t := L.NewTable()
+	L.SetField(t, "err", lua.LString(message))
+	return t
+}
+
+// toLua converts a reply of a command to a Lua value like redis does.
+func toLua(L *lua.LState, reply interface{}) lua.LValue {
+	switch v := reply.(type) {
+	case nil:
+		return lua.LFalse
+	case int64:
+		return lua.LNumber(v)
+	case string:
+		return lua.LString(v)
+	case status:
+		t := L.NewTable()
+		L.SetField(t, "ok", lua.LString(v))
+		return t
+	case []interface{}:
+		t := L.CreateTable(len(v), 0)
+		for _, item := range v {
+			t.Append(toLua(L, item))
+		}
+		return t
+	default:
+		return lua.LNil
+	}
+}
+
+// fromLua converts the value returned by a script to a reply like redis does.
+func fromLua(value lua.LValue) (interface{}, error) {
+	switch v := value.(type) {
+	case lua.LNumber:
+		return int64(v), nil
+	case lua.LString:
+		return string(v), nil
+	case lua.LBool:
+		if v {
+			return int64(1), nil
+		}
+		return nil, nil
+	case *lua.LTable:
+		if errValue := v.RawGetString("err"); errValue != lua.LNil {
+			return nil, replyError(strings.TrimSpace(errValue.String()))
+		}
+		if ok := v.RawGetString("ok"); ok != lua.LNil {
+			return ok.String(), nil
+		}
+
+		var reply []interface{}
+		for i := 1; ; i++ {
+			item := v.RawGetInt(i)
+			if item == lua.LNil {
+				break
+			}
+			converted, err := fromLua(item)
+			if err != nil {
+				return nil, err
+			}
+			reply = append(reply, converted)
+		}
+		return reply, nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,64 @@
+package redislocktest_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/XdpCs/redis-lock/redislocktest"
+)
+
+func TestPartitionManager_FairShare(t *testing.T) {
+	client, err := redislock.NewClient(redislocktest.NewRedis())
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var managers []*redislock.PartitionManager
+	done := make(chan struct{})
+	for _, member := range []string{"memberOne", "memberTwo", "memberThree"} {
+		manager, err := client.NewPartitionManager("testGroup", member, 10,
+			redislock.WithPartitionExpiration(time.Second),
+			redislock.WithRebalanceInterval(20*time.Millisecond),
+		)
+		if err != nil {
+			t.Fatalf("NewPartitionManager error:[%v]", err)
+		}
+		managers = append(managers, manager)
+
+		go func() {
+			_ = manager.Run(ctx)
+			done <- struct{}{}
+		}()
+	}
+
+	// 10 partitions among 3 members are owned 4, 3 and 3.
+	expected := []int{4, 3, 3}
+	var shares []int
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		shares = shares[:0]
+		for _, manager := range managers {
+			shares = append(shares, len(manager.Owned()))
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(shares)))
+		if shares[0] == expected[0] && shares[1] == expected[1] && shares[2] == expected[2] {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if shares[0] != expected[0] || shares[1] != expected[1] || shares[2] != expected[2] {
+		t.Fatalf("shares is not equal,expected %v, got %v", expected, shares)
+	}
+
+	cancel()
+	for range managers {
+		<-done
+	}
+}
@@ -0,0 +1,954 @@
+// Package redislocktest provides an in-memory implementation of redislock.RedisClient,
+// so code using redislock can be tested without a redis server.
+package redislocktest
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	errWrongType    = replyError("WRONGTYPE Operation against a key holding the wrong kind of value")
+	errNotInteger   = replyError("ERR value is not an integer or out of range")
+	errNotFloat     = replyError("ERR value is not a valid float")
+	errSyntax       = replyError("ERR syntax error")
+	errNoScript     = replyError("NOSCRIPT No matching script. Please use EVAL.")
+	errWrongArgsNum = replyError("ERR wrong number of arguments")
+)
+
+// replyError is an error reply of redis, go-redis recognizes it as redis.Error.
+type replyError string
+
+func (e replyError) Error() string {
+	return string(e)
+}
+
+func (replyError) RedisError() {}
+
+// Clock tells the time of Redis, it drives the expiration of keys.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the system clock, it implements redislock.Clock and Clock.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	timer := time.NewTimer(d)
+	return timer.C, timer.Stop
+}
+
+func (systemClock) WithDeadline(ctx context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	return context.WithDeadline(ctx, deadline)
+}
+
+// Redis is an in-memory redislock.RedisClient, it supports SetNX and the scripts of redislock,
+// which are run by a Lua interpreter against the commands redislock uses, as scripts or as functions.
+// Pub/sub is not supported, so redislock polls instead of waiting for notifications.
+type Redis struct {
+	clock Clock
+
+	mu        sync.Mutex
+	keys      map[string]*entry
+	scripts   map[string]string   // scripts by their sha1.
+	libraries map[string]*library // function libraries by name.
+	replicas  int                 // replicas acknowledging WAIT.
+}
+
+// entry is a key of Redis, value is a string, a hash or a sorted set.
+type entry struct {
+	value    interface{}
+	expireAt time.Time // zero means no expiration.
+}
+
+type (
+	hash      map[string]string
+	sortedSet map[string]float64
+)
+
+// status is a status reply, such as OK.
+type status string
+
+// NewRedis creates a new Redis.
+func NewRedis(options ...Option) *Redis {
+	r := &Redis{
+		clock:     systemClock{},
+		keys:      make(map[string]*entry),
+		scripts:   make(map[string]string),
+		libraries: make(map[string]*library),
+	}
+
+	for _, option := range options {
+		option(r)
+	}
+
+	return r
+}
+
+type Option func(redis *Redis)
+
+// WithClock sets the clock of Redis, default is the system clock.
+func WithClock(clock Clock) Option {
+	return func(redis *Redis) {
+		redis.clock = clock
+	}
+}
+
+// SetNX sets key to value if key does not exist.
+func (r *Redis) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	args := []string{"set", key, toString(value), "nx"}
+	if expiration > 0 {
+		args = append(args, "px", strconv.FormatInt(expiration.Milliseconds(), 10))
+	}
+
+	reply, err := r.Do(args...)
+	return redis.NewBoolResult(reply != nil, err)
+}
+
+// Get returns the value of key.
+func (r *Redis) Get(ctx context.Context, key string) *redis.StringCmd {
+	reply, err := r.Do("get", key)
+	if err == nil && reply == nil {
+		err = redis.Nil
+	}
+	value, _ := reply.(string)
+	return redis.NewStringResult(value, err)
+}
+
+// Eval runs script.
+func (r *Redis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	r.mu.Lock()
+	r.scripts[sha1Hex(script)] = script
+	r.mu.Unlock()
+
+	return r.eval(script, keys, args)
+}
+
+// EvalSha runs the script loaded as sha1.
+func (r *Redis) EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd {
+	r.mu.Lock()
+	script, ok := r.scripts[sha1]
+	r.mu.Unlock()
+
+	if !ok {
+		return redis.NewCmdResult(nil, errNoScript)
+	}
+	return r.eval(script, keys, args)
+}
+
+// EvalRO runs script, the script is not checked to be read-only.
+func (r *Redis) EvalRO(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	return r.Eval(ctx, script, keys, args...)
+}
+
+// EvalShaRO runs the script loaded as sha1, the script is not checked to be read-only.
+func (r *Redis) EvalShaRO(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd {
+	return r.EvalSha(ctx, sha1, keys, args...)
+}
+
+// ScriptExists returns whether the scripts of hashes are loaded.
+func (r *Redis) ScriptExists(ctx context.Context, hashes ...string) *redis.BoolSliceCmd {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	exists := make([]bool, len(hashes))
+	for i, h := range hashes {
+		_, exists[i] = r.scripts[h]
+	}
+	return redis.NewBoolSliceResult(exists, nil)
+}
+
+// ScriptLoad loads script and returns its sha1.
+func (r *Redis) ScriptLoad(ctx context.Context, script string) *redis.StringCmd {
+	sha := sha1Hex(script)
+
+	r.mu.Lock()
+	r.scripts[sha] = script
+	r.mu.Unlock()
+
+	return redis.NewStringResult(sha, nil)
+}
+
+// ScriptFlush removes all loaded scripts, like a restarted redis server.
+func (r *Redis) ScriptFlush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.scripts = make(map[string]string)
+}
+
+// SetReplicas sets the count of replicas, which acknowledge every write at once, WAIT returns it.
+func (r *Redis) SetReplicas(replicas int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.replicas = replicas
+}
+
+// Wait returns the count of replicas set by SetReplicas, all of them acknowledged every write already.
+func (r *Redis) Wait(ctx context.Context, numReplicas int, timeout time.Duration) *redis.IntCmd {
+	reply, err := r.Do("wait", strconv.Itoa(numReplicas), strconv.FormatInt(timeout.Milliseconds(), 10))
+	replicas, _ := reply.(int64)
+	return redis.NewIntResult(replicas, err)
+}
+
+// FlushAll removes all keys.
+func (r *Redis) FlushAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys = make(map[string]*entry)
+}
+
+// Do runs a command, such as SET, EVALSHA or SCRIPT LOAD,
+// the reply is nil, an int64, a string or a []interface{} of them.
+// It lets Redis back clients of other libraries in tests.
+func (r *Redis) Do(args ...string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reply, err := r.do(args)
+	if s, ok := reply.(status); ok {
+		return string(s), err
+	}
+	return reply, err
+}
+
+func (r *Redis) eval(script string, keys []string, args []interface{}) *redis.Cmd {
+	argv := make([]string, len(args))
+	for i, arg := range args {
+		argv[i] = toString(arg)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reply, err := r.runScript(script, keys, argv)
+	if err != nil {
+		return redis.NewCmdResult(nil, err)
+	}
+	if reply == nil {
+		return redis.NewCmdResult(nil, redis.Nil)
+	}
+	return redis.NewCmdResult(reply, nil)
+}
+
+// do runs a command, r.mu must be held.
+func (r *Redis) do(args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, errWrongArgsNum
+	}
+
+	command, ok := commands[strings.ToLower(args[0])]
+	if !ok {
+		return nil, replyError(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+
+	if len(args)-1 < command.minArgs {
+		return nil, replyError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", args[0]))
+	}
+	return command.run(r, args[1:])
+}
+
+// get returns the alive entry of key, nil if key does not exist.
+func (r *Redis) get(key string) *entry {
+	e, ok := r.keys[key]
+	if !ok {
+		return nil
+	}
+
+	if !e.expireAt.IsZero() && !r.clock.Now().Before(e.expireAt) {
+		delete(r.keys, key)
+		return nil
+	}
+	return e
+}
+
+func (r *Redis) getString(key string) (string, bool, error) {
+	e := r.get(key)
+	if e == nil {
+		return "", false, nil
+	}
+
+	s, ok := e.value.(string)
+	if !ok {
+		return "", false, errWrongType
+	}
+	return s, true, nil
+}
+
+// getHash returns the hash of key, if create is true, a missing hash is created.
+func (r *Redis) getHash(key string, create bool) (hash, error) {
+	e := r.get(key)
+	if e == nil {
+		if !create {
+			return nil, nil
+		}
+		h := make(hash)
+		r.keys[key] = &entry{value: h}
+		return h, nil
+	}
+
+	h, ok := e.value.(hash)
+	if !ok {
+		return nil, errWrongType
+	}
+	return h, nil
+}
+
+// getSortedSet returns the sorted set of key, if create is true, a missing sorted set is created.
+func (r *Redis) getSortedSet(key string, create bool) (sortedSet, error) {
+	e := r.get(key)
+	if e == nil {
+		if !create {
+			return nil, nil
+		}
+		z := make(sortedSet)
+		r.keys[key] = &entry{value: z}
+		return z, nil
+	}
+
+	z, ok := e.value.(sortedSet)
+	if !ok {
+		return nil, errWrongType
+	}
+	return z, nil
+}
+
+// deleteIfEmpty deletes key if it is an empty hash or sorted set, like redis does.
+func (r *Redis) deleteIfEmpty(key string) {
+	e, ok := r.keys[key]
+	if !ok {
+		return
+	}
+
+	switch v := e.value.(type) {
+	case hash:
+		if len(v) == 0 {
+			delete(r.keys, key)
+		}
+	case sortedSet:
+		if len(v) == 0 {
+			delete(r.keys, key)
+		}
+	}
+}
+
+type command struct {
+	minArgs int
+	run     func(r *Redis, args []string) (interface{}, error)
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"ping":             {0, cmdPing},
+		"time":             {0, cmdTime},
+		"get":              {1, cmdGet},
+		"set":              {2, cmdSet},
+		"del":              {1, cmdDel},
+		"exists":           {1, cmdExists},
+		"pexpire":          {2, cmdPExpire},
+		"expire":           {2, cmdExpire},
+		"pttl":             {1, cmdPTTL},
+		"ttl":              {1, cmdTTL},
+		"incr":             {1, cmdIncr},
+		"decr":             {1, cmdDecr},
+		"incrby":           {2, cmdIncrBy},
+		"decrby":           {2, cmdDecrBy},
+		"hget":             {2, cmdHGet},
+		"hset":             {3, cmdHSet},
+		"hsetnx":           {3, cmdHSetNX},
+		"hexists":          {2, cmdHExists},
+		"hdel":             {2, cmdHDel},
+		"hincrby":          {3, cmdHIncrBy},
+		"hlen":             {1, cmdHLen},
+		"hgetall":          {1, cmdHGetAll},
+		"zadd":             {3, cmdZAdd},
+		"zrem":             {2, cmdZRem},
+		"zcard":            {1, cmdZCard},
+		"zscore":           {2, cmdZScore},
+		"zrange":           {3, cmdZRange},
+		"zremrangebyscore": {3, cmdZRemRangeByScore},
+		"publish":          {2, cmdPublish},
+		"eval":             {2, cmdEval},
+		"evalsha":          {2, cmdEvalSha},
+		"script":           {1, cmdScript},
+		"fcall":            {2, cmdFCall},
+		"function":         {1, cmdFunction},
+		"wait":             {2, cmdWait},
+	}
+}
+
+func cmdPing(r *Redis, args []string) (interface{}, error) {
+	return status("PONG"), nil
+}
+
+func cmdTime(r *Redis, args []string) (interface{}, error) {
+	now := r.clock.Now()
+	return []interface{}{
+		strconv.FormatInt(now.Unix(), 10),
+		strconv.FormatInt(int64(now.Nanosecond()/1000), 10),
+	}, nil
+}
+
+func cmdGet(r *Redis, args []string) (interface{}, error) {
+	s, ok, err := r.getString(args[0])
+	if err != nil || !ok {
+		return nil, err
+	}
+	return s, nil
+}
+
+func cmdSet(r *Redis, args []string) (interface{}, error) {
+	key, value := args[0], args[1]
+	var nx, xx, keepTTL bool
+	var expireAt time.Time
+	for i := 2; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "keepttl":
+			keepTTL = true
+		case "px", "ex":
+			if i+1 >= len(args) {
+				return nil, errSyntax
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || n <= 0 {
+				return nil, replyError("ERR invalid expire time in 'set' command")
+			}
+			unit := time.Millisecond
+			if strings.ToLower(args[i]) == "ex" {
+				unit = time.Second
+			}
+			expireAt = r.clock.Now().Add(time.Duration(n) * unit)
+			i++
+		default:
+			return nil, errSyntax
+		}
+	}
+
+	old := r.get(key)
+	if (nx && old != nil) || (xx && old == nil) {
+		return nil, nil
+	}
+
+	if keepTTL && old != nil {
+		expireAt = old.expireAt
+	}
+	r.keys[key] = &entry{value: value, expireAt: expireAt}
+	return status("OK"), nil
+}
+
+func cmdDel(r *Redis, args []string) (interface{}, error) {
+	var deleted int64
+	for _, key := range args {
+		if r.get(key) != nil {
+			delete(r.keys, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func cmdExists(r *Redis, args []string) (interface{}, error) {
+	var exists int64
+	for _, key := range args {
+		if r.get(key) != nil {
+			exists++
+		}
+	}
+	return exists, nil
+}
+
+func cmdPExpire(r *Redis, args []string) (interface{}, error) {
+	return expire(r, args, time.Millisecond)
+}
+
+func cmdExpire(r *Redis, args []string) (interface{}, error) {
+	return expire(r, args, time.Second)
+}
+
+func expire(r *Redis, args []string, unit time.Duration) (interface{}, error) {
+	n, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, errNotInteger
+	}
+
+	e := r.get(args[0])
+	if e == nil {
+		return int64(0), nil
+	}
+
+	if n <= 0 {
+		delete(r.keys, args[0])
+		return int64(1), nil
+	}
+	e.expireAt = r.clock.Now().Add(time.Duration(n) * unit)
+	return int64(1), nil
+}
+
+func cmdPTTL(r *Redis, args []string) (interface{}, error) {
+	return ttl(r, args[0], time.Millisecond), nil
+}
+
+func cmdTTL(r *Redis, args []string) (interface{}, error) {
+	return ttl(r, args[0], time.Second), nil
+}
+
+func ttl(r *Redis, key string, unit time.Duration) int64 {
+	e := r.get(key)
+	if e == nil {
+		return -2
+	}
+
+	if e.expireAt.IsZero() {
+		return -1
+	}
+	// round up like redis, so an alive key never has a zero ttl.
+	remaining := e.expireAt.Sub(r.clock.Now())
+	return int64((remaining + unit - 1) / unit)
+}
+
+func cmdIncr(r *Redis, args []string) (interface{}, error) {
+	return incrBy(r, args[0], 1)
+}
+
+func cmdDecr(r *Redis, args []string) (interface{}, error) {
+	return incrBy(r, args[0], -1)
+}
+
+func cmdIncrBy(r *Redis, args []string) (interface{}, error) {
+	n, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, errNotInteger
+	}
+	return incrBy(r, args[0], n)
+}
+
+func cmdDecrBy(r *Redis, args []string) (interface{}, error) {
+	n, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return nil, errNotInteger
+	}
+	return incrBy(r, args[0], -n)
+}
+
+func incrBy(r *Redis, key string, n int64) (interface{}, error) {
+	s, ok, err := r.getString(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var value int64
+	if ok {
+		if value, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return nil, errNotInteger
+		}
+	}
+	value += n
+
+	if e := r.get(key); e != nil {
+		e.value = strconv.FormatInt(value, 10)
+	} else {
+		r.keys[key] = &entry{value: strconv.FormatInt(value, 10)}
+	}
+	return value, nil
+}
+
+func cmdHGet(r *Redis, args []string) (interface{}, error) {
+	h, err := r.getHash(args[0], false)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := h[args[1]]
+	if !ok {
+		return nil, nil
+	}
+	return value, nil
+}
+
+func cmdHSet(r *Redis, args []string) (interface{}, error) {
+	if len(args)%2 != 1 {
+		return nil, errWrongArgsNum
+	}
+
+	h, err := r.getHash(args[0], true)
+	if err != nil {
+		return nil, err
+	}
+
+	var added int64
+	for i := 1; i < len(args); i += 2 {
+		if _, ok := h[args[i]]; !ok {
+			added++
+		}
+		h[args[i]] = args[i+1]
+	}
+	return added, nil
+}
+
+func cmdHSetNX(r *Redis, args []string) (interface{}, error) {
+	h, err := r.getHash(args[0], true)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := h[args[1]]; ok {
+		return int64(0), nil
+	}
+	h[args[1]] = args[2]
+	return int64(1), nil
+}
+
+func cmdHExists(r *Redis, args []string) (interface{}, error) {
+	h, err := r.getHash(args[0], false)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := h[args[1]]; ok {
+		return int64(1), nil
+	}
+	return int64(0), nil
+}
+
+func cmdHDel(r *Redis, args []string) (interface{}, error) {
+	h, err := r.getHash(args[0], false)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted int64
+	for _, field := range args[1:] {
+		if _, ok := h[field]; ok {
+			delete(h, field)
+			deleted++
+		}
+	}
+	r.deleteIfEmpty(args[0])
+	return deleted, nil
+}
+
+func cmdHIncrBy(r *Redis, args []string) (interface{}, error) {
+	n, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return nil, errNotInteger
+	}
+
+	h, err := r.getHash(args[0], true)
+	if err != nil {
+		return nil, err
+	}
+
+	var value int64
+	if s, ok := h[args[1]]; ok {
+		if value, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return nil, errNotInteger
+		}
+	}
+	value += n
+	h[args[1]] = strconv.FormatInt(value, 10)
+	return value, nil
+}
+
+func cmdHLen(r *Redis, args []string) (interface{}, error) {
+	h, err := r.getHash(args[0], false)
+	if err != nil {
+		return nil, err
+	}
+	return int64(len(h)), nil
+}
+
+func cmdHGetAll(r *Redis, args []string) (interface{}, error) {
+	h, err := r.getHash(args[0], false)
+	if err != nil {
+		return nil, err
+	}
+
+	reply := make([]interface{}, 0, 2*len(h))
+	for field, value := range h {
+		reply = append(reply, field, value)
+	}
+	return reply, nil
+}
+
+func cmdZAdd(r *Redis, args []string) (interface{}, error) {
+	if len(args)%2 != 1 {
+		return nil, errSyntax
+	}
+
+	z, err := r.getSortedSet(args[0], true)
+	if err != nil {
+		return nil, err
+	}
+
+	var added int64
+	for i := 1; i < len(args); i += 2 {
+		score, err := strconv.ParseFloat(args[i], 64)
+		if err != nil {
+			r.deleteIfEmpty(args[0])
+			return nil, errNotFloat
+		}
+		if _, ok := z[args[i+1]]; !ok {
+			added++
+		}
+		z[args[i+1]] = score
+	}
+	return added, nil
+}
+
+func cmdZRem(r *Redis, args []string) (interface{}, error) {
+	z, err := r.getSortedSet(args[0], false)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed int64
+	for _, member := range args[1:] {
+		if _, ok := z[member]; ok {
+			delete(z, member)
+			removed++
+		}
+	}
+	r.deleteIfEmpty(args[0])
+	return removed, nil
+}
+
+func cmdZCard(r *Redis, args []string) (interface{}, error) {
+	z, err := r.getSortedSet(args[0], false)
+	if err != nil {
+		return nil, err
+	}
+	return int64(len(z)), nil
+}
+
+func cmdZScore(r *Redis, args []string) (interface{}, error) {
+	z, err := r.getSortedSet(args[0], false)
+	if err != nil {
+		return nil, err
+	}
+
+	score, ok := z[args[1]]
+	if !ok {
+		return nil, nil
+	}
+	return strconv.FormatFloat(score, 'f', -1, 64), nil
+}
+
+// cmdZRange returns the members from index start to stop, ordered by score then member,
+// the options of redis 6.2 are not supported.
+func cmdZRange(r *Redis, args []string) (interface{}, error) {
+	start, err := strconv.Atoi(args[1])
+	if err != nil {
+		return nil, errNotInteger
+	}
+	stop, err := strconv.Atoi(args[2])
+	if err != nil {
+		return nil, errNotInteger
+	}
+
+	z, err := r.getSortedSet(args[0], false)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]string, 0, len(z))
+	for member := range z {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if z[members[i]] != z[members[j]] {
+			return z[members[i]] < z[members[j]]
+		}
+		return members[i] < members[j]
+	})
+
+	if start < 0 {
+		start += len(members)
+	}
+	if stop < 0 {
+		stop += len(members)
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= len(members) {
+		stop = len(members) - 1
+	}
+
+	reply := make([]interface{}, 0)
+	for i := start; i <= stop; i++ {
+		reply = append(reply, members[i])
+	}
+	return reply, nil
+}
+
+func cmdZRemRangeByScore(r *Redis, args []string) (interface{}, error) {
+	min, minExclusive, err := parseScore(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	max, maxExclusive, err := parseScore(args[2])
+	if err != nil {
+		return nil, err
+	}
+
+	z, err := r.getSortedSet(args[0], false)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed int64
+	for member, score := range z {
+		if (score > min || (!minExclusive && score == min)) && (score < max || (!maxExclusive && score == max)) {
+			delete(z, member)
+			removed++
+		}
+	}
+	r.deleteIfEmpty(args[0])
+	return removed, nil
+}
+
+// parseScore parses a score range bound, such as "-inf", "(5" or "5".
+func parseScore(s string) (score float64, exclusive bool, err error) {
+	if strings.HasPrefix(s, "(") {
+		exclusive = true
+		s = s[1:]
+	}
+
+	switch strings.ToLower(s) {
+	case "-inf":
+		return math.Inf(-1), exclusive, nil
+	case "+inf", "inf":
+		return math.Inf(1), exclusive, nil
+	}
+
+	score, err = strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false, replyError("ERR min or max is not a float")
+	}
+	return score, exclusive, nil
+}
+
+func cmdPublish(r *Redis, args []string) (interface{}, error) {
+	// pub/sub is not supported, so there is no subscriber.
+	return int64(0), nil
+}
+
+func cmdWait(r *Redis, args []string) (interface{}, error) {
+	for _, arg := range args {
+		if n, err := strconv.ParseInt(arg, 10, 64); err != nil || n < 0 {
+			return nil, errNotInteger
+		}
+	}
+	return int64(r.replicas), nil
+}
+
+func cmdEval(r *Redis, args []string) (interface{}, error) {
+	r.scripts[sha1Hex(args[0])] = args[0]
+	return evalScript(r, args[0], args[1:])
+}
+
+func cmdEvalSha(r *Redis, args []string) (interface{}, error) {
+	script, ok := r.scripts[strings.ToLower(args[0])]
+	if !ok {
+		return nil, errNoScript
+	}
+	return evalScript(r, script, args[1:])
+}
+
+// evalScript runs script with args starting at numkeys, like EVAL.
+func evalScript(r *Redis, script string, args []string) (interface{}, error) {
+	numKeys, err := strconv.Atoi(args[0])
+	if err != nil || numKeys < 0 {
+		return nil, replyError("ERR Number of keys can't be negative")
+	}
+	if numKeys > len(args)-1 {
+		return nil, replyError("ERR Number of keys can't be greater than number of args")
+	}
+	return r.runScript(script, args[1:1+numKeys], args[1+numKeys:])
+}
+
+func cmdScript(r *Redis, args []string) (interface{}, error) {
+	switch strings.ToLower(args[0]) {
+	case "load":
+		if len(args) != 2 {
+			return nil, errWrongArgsNum
+		}
+		sha := sha1Hex(args[1])
+		r.scripts[sha] = args[1]
+		return sha, nil
+	case "exists":
+		exists := make([]interface{}, len(args)-1)
+		for i, sha := range args[1:] {
+			exists[i] = int64(0)
+			if _, ok := r.scripts[strings.ToLower(sha)]; ok {
+				exists[i] = int64(1)
+			}
+		}
+		return exists, nil
+	case "flush":
+		r.scripts = make(map[string]string)
+		return status("OK"), nil
+	default:
+		return nil, replyError(fmt.Sprintf("ERR unknown subcommand '%s'", args[0]))
+	}
+}
+
+func sha1Hex(script string) string {
+	sum := sha1.Sum([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+// toString formats a command argument like go-redis does.
+func toString(arg interface{}) string {
+	switch v := arg.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Duration:
+		return strconv.FormatInt(int64(v), 10)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
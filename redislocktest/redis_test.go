@@ -0,0 +1,255 @@
+package redislocktest_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/XdpCs/redis-lock/redislocktest"
+)
+
+func TestRedis_Mutex(t *testing.T) {
+	clock := redislocktest.NewFakeClock(time.Unix(1700000000, 0))
+	rdb := redislocktest.NewRedis(redislocktest.WithClock(clock))
+	client, err := redislock.NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+
+	ctx := context.Background()
+	key := "test"
+
+	mutex, err := client.TryLock(ctx, key, 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+
+	if _, err = client.TryLock(ctx, key, 10*time.Second); !redislock.IsMutexLockFailed(err) {
+		t.Fatalf("TryLock is not equal,expected %v, got %v", redislock.ErrMutexLockFailed, err)
+	}
+
+	clock.Advance(4 * time.Second)
+	ttl, err := mutex.TTL(ctx)
+	if err != nil {
+		t.Fatalf("TTL error:[%v]", err)
+	}
+	if ttl != 6*time.Second {
+		t.Fatalf("TTL is not equal,expected %v, got %v", 6*time.Second, ttl)
+	}
+
+	if err = mutex.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh error:[%v]", err)
+	}
+
+	clock.Advance(9 * time.Second)
+	if err = mutex.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+
+	// the lock expires without being unlocked.
+	if _, err = client.TryLock(ctx, key, 10*time.Second); err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+	clock.Advance(10 * time.Second)
+	if _, err = client.TryLock(ctx, key, 10*time.Second); err != nil {
+		t.Fatalf("TryLock after expiration error:[%v]", err)
+	}
+}
+
+func TestRedis_Fencing(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	client, err := redislock.NewClient(rdb, redislock.WithFencing())
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+
+	ctx := context.Background()
+	key := "test"
+
+	for i := int64(1); i <= 3; i++ {
+		mutex, err := client.TryLock(ctx, key, 10*time.Second)
+		if err != nil {
+			t.Fatalf("TryLock error:[%v]", err)
+		}
+		if mutex.FencingToken() != i {
+			t.Fatalf("FencingToken is not equal,expected %v, got %v", i, mutex.FencingToken())
+		}
+		if err = mutex.Unlock(ctx); err != nil {
+			t.Fatalf("Unlock error:[%v]", err)
+		}
+	}
+}
+
+func TestRedis_RWMutex(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	client, err := redislock.NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+
+	ctx := context.Background()
+	key := "test"
+
+	readerOne, err := client.TryReadLock(ctx, key, 10*time.Second, redislock.NewNoRetry())
+	if err != nil {
+		t.Fatalf("readerOne TryReadLock error:[%v]", err)
+	}
+	readerTwo, err := client.TryReadLock(ctx, key, 10*time.Second, redislock.NewNoRetry())
+	if err != nil {
+		t.Fatalf("readerTwo TryReadLock error:[%v]", err)
+	}
+
+	if _, err = client.TryWriteLock(ctx, key, 10*time.Second, redislock.NewNoRetry()); !redislock.IsMutexLockFailed(err) {
+		t.Fatalf("TryWriteLock is not equal,expected %v, got %v", redislock.ErrMutexLockFailed, err)
+	}
+
+	if err = readerOne.Unlock(ctx); err != nil {
+		t.Fatalf("readerOne Unlock error:[%v]", err)
+	}
+	if err = readerTwo.Unlock(ctx); err != nil {
+		t.Fatalf("readerTwo Unlock error:[%v]", err)
+	}
+
+	writer, err := client.TryWriteLock(ctx, key, 10*time.Second, redislock.NewNoRetry())
+	if err != nil {
+		t.Fatalf("TryWriteLock error:[%v]", err)
+	}
+	if err = writer.Unlock(ctx); err != nil {
+		t.Fatalf("writer Unlock error:[%v]", err)
+	}
+}
+
+func TestRedis_EvalSha(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	ctx := context.Background()
+	script := `return redis.call("incr", KEYS[1])`
+
+	// the script is not loaded yet.
+	if err := rdb.EvalSha(ctx, "0000", []string{"counter"}).Err(); err == nil {
+		t.Fatalf("EvalSha is not equal,expected NOSCRIPT error, got nil")
+	}
+
+	sha, err := rdb.ScriptLoad(ctx, script).Result()
+	if err != nil {
+		t.Fatalf("ScriptLoad error:[%v]", err)
+	}
+
+	exists, err := rdb.ScriptExists(ctx, sha).Result()
+	if err != nil {
+		t.Fatalf("ScriptExists error:[%v]", err)
+	}
+	if !exists[0] {
+		t.Fatalf("ScriptExists is not equal,expected %v, got %v", true, exists[0])
+	}
+
+	for i := int64(1); i <= 2; i++ {
+		actual, err := rdb.EvalSha(ctx, sha, []string{"counter"}).Int64()
+		if err != nil {
+			t.Fatalf("EvalSha error:[%v]", err)
+		}
+		if actual != i {
+			t.Fatalf("EvalSha is not equal,expected %v, got %v", i, actual)
+		}
+	}
+
+	rdb.ScriptFlush()
+	if err = rdb.EvalSha(ctx, sha, []string{"counter"}).Err(); err == nil {
+		t.Fatalf("EvalSha is not equal,expected NOSCRIPT error, got nil")
+	}
+}
+
+func TestRedis_DoEval(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	script := `return redis.call("incrby", KEYS[1], ARGV[1])`
+
+	sha, err := rdb.Do("script", "load", script)
+	if err != nil {
+		t.Fatalf("Do script load error:[%v]", err)
+	}
+
+	// test cases
+	tests := []struct {
+		Name     string
+		Args     []string
+		Expected interface{}
+	}{
+		{"Eval", []string{"eval", script, "1", "counter", "2"}, int64(2)},
+		{"EvalSha", []string{"evalsha", sha.(string), "1", "counter", "3"}, int64(5)},
+		{"ScriptExists", []string{"script", "exists", sha.(string), "0000"}, []interface{}{int64(1), int64(0)}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			actual, err := rdb.Do(tc.Args...)
+			if err != nil {
+				t.Fatalf("Do error:[%v]", err)
+			}
+			if fmt.Sprint(actual) != fmt.Sprint(tc.Expected) {
+				t.Fatalf("Do is not equal,expected %v, got %v", tc.Expected, actual)
+			}
+		})
+	}
+
+	if _, err = rdb.Do("eval", `return redis.call("eval", "return 1", 0)`, "0"); err == nil {
+		t.Fatalf("Do is not equal,expected error of eval from script, got nil")
+	}
+}
+
+func TestRedis_DoFunction(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	library := "#!lua name=test\n" +
+		"redis.register_function('incr', function(keys, args) return redis.call('incrby', keys[1], args[1]) end)\n" +
+		"redis.register_function{function_name='version', callback=function() return 1 end, flags={'no-writes'}}"
+
+	// test cases
+	tests := []struct {
+		Name     string
+		Args     []string
+		Expected interface{}
+		Err      string
+	}{
+		{"Load", []string{"function", "load", library}, "test", ""},
+		{"LoadExists", []string{"function", "load", library}, nil, "ERR Library 'test' already exists"},
+		{"LoadReplace", []string{"function", "load", "replace", library}, "test", ""},
+		{"LoadOtherLibrary", []string{"function", "load", strings.Replace(library, "name=test", "name=other", 1)}, nil, "ERR Function incr already exists"},
+		{"FCall", []string{"fcall", "incr", "1", "counter", "2"}, int64(2), ""},
+		{"FCallNoKeys", []string{"fcall", "version", "0"}, int64(1), ""},
+		{"FCallNotFound", []string{"fcall", "decr", "1", "counter"}, nil, "ERR Function not found"},
+		{"Delete", []string{"function", "delete", "test"}, "OK", ""},
+		{"FCallDeleted", []string{"fcall", "incr", "1", "counter", "2"}, nil, "ERR Function not found"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			actual, err := rdb.Do(tc.Args...)
+			if tc.Err != "" {
+				if err == nil || err.Error() != tc.Err {
+					t.Fatalf("Do error is not equal,expected %v, got %v", tc.Err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Do error:[%v]", err)
+			}
+			if actual != tc.Expected {
+				t.Fatalf("Do is not equal,expected %v, got %v", tc.Expected, actual)
+			}
+		})
+	}
+}
+
+func TestRedis_DoWait(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	rdb.SetReplicas(2)
+
+	acked, err := rdb.Do("wait", "1", "100")
+	if err != nil {
+		t.Fatalf("Do wait error:[%v]", err)
+	}
+	if acked != int64(2) {
+		t.Fatalf("Do wait is not equal,expected %v, got %v", 2, acked)
+	}
+}
@@ -0,0 +1,334 @@
+package redislocktest
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+)
+
+// kinds of Event.
+const (
+	EventAcquire = "acquire"
+	EventRelease = "release"
+	EventExpire  = "expire" // the holder released the lock after its deadline, so the lock expired first.
+)
+
+// Event is a lock event of a client.
+type Event struct {
+	Kind         string
+	Client       int
+	Key          string
+	FencingToken int64
+	Time         time.Time
+}
+
+// Hold is the interval in which a client believed it held the lock of Key,
+// from the return of the acquire until the release started or the lock expired.
+type Hold struct {
+	Client       int
+	Key          string
+	FencingToken int64
+	Start        time.Time
+	End          time.Time
+}
+
+// Violation is a pair of holds of a key breaking mutual exclusion or the order of fencing tokens.
+type Violation struct {
+	First  Hold
+	Second Hold
+	Reason string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("key %q: client %d held [%s, %s] with token %d, client %d held [%s, %s] with token %d: %s",
+		v.First.Key,
+		v.First.Client, v.First.Start.Format(time.RFC3339Nano), v.First.End.Format(time.RFC3339Nano), v.First.FencingToken,
+		v.Second.Client, v.Second.Start.Format(time.RFC3339Nano), v.Second.End.Format(time.RFC3339Nano), v.Second.FencingToken,
+		v.Reason)
+}
+
+// History records the lock events of clients, it is safe for concurrent use.
+type History struct {
+	clock Clock
+
+	mu     sync.Mutex
+	events []Event
+	holds  []Hold
+	open   map[*redislock.Mutex]int // index in holds of the mutexes not released yet.
+}
+
+// NewHistory creates a new History.
+func NewHistory(options ...HistoryOption) *History {
+	h := &History{clock: systemClock{}, open: make(map[*redislock.Mutex]int)}
+
+	for _, option := range options {
+		option(h)
+	}
+
+	return h
+}
+
+type HistoryOption func(history *History)
+
+// WithHistoryClock sets the clock of History, it should be the clock of the redislock clients.
+func WithHistoryClock(clock Clock) HistoryOption {
+	return func(history *History) {
+		history.clock = clock
+	}
+}
+
+// Acquired records that client acquired mutex, call it as soon as the acquire returns.
+func (h *History) Acquired(client int, mutex *redislock.Mutex) {
+	now := h.clock.Now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.events = append(h.events, Event{Kind: EventAcquire, Client: client, Key: mutex.Key(), FencingToken: mutex.FencingToken(), Time: now})
+	h.open[mutex] = len(h.holds)
+	h.holds = append(h.holds, Hold{Client: client, Key: mutex.Key(), FencingToken: mutex.FencingToken(), Start: now})
+}
+
+// Released records that client stops using mutex, call it before the unlock is sent.
+func (h *History) Released(client int, mutex *redislock.Mutex) {
+	now := h.clock.Now()
+	deadline := mutex.Deadline()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	i, ok := h.open[mutex]
+	if !ok {
+		return
+	}
+	delete(h.open, mutex)
+
+	event := Event{Kind: EventRelease, Client: client, Key: mutex.Key(), FencingToken: mutex.FencingToken(), Time: now}
+	if deadline.Before(now) {
+		event.Kind, event.Time = EventExpire, deadline
+	}
+	h.events = append(h.events, event)
+	h.holds[i].End = event.Time
+}
+
+// Events returns the recorded events in the order they were recorded.
+func (h *History) Events() []Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	events := make([]Event, len(h.events))
+	copy(events, h.events)
+	return events
+}
+
+// Holds returns the recorded holds, a hold not released yet ends at the last known deadline of its mutex.
+func (h *History) Holds() []Hold {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	holds := make([]Hold, len(h.holds))
+	copy(holds, h.holds)
+	for mutex, i := range h.open {
+		holds[i].End = mutex.Deadline()
+	}
+	return holds
+}
+
+// Check returns the violations of the recorded holds, see CheckHolds.
+func (h *History) Check() []Violation {
+	return CheckHolds(h.Holds())
+}
+
+// CheckHolds checks that no two holds of a key overlap,
+// and that fencing tokens increase from a hold to the later ones of its key,
+// holds with a zero fencing token are not checked for the order of tokens.
+func CheckHolds(holds []Hold) []Violation {
+	byKey := make(map[string][]Hold)
+	for _, hold := range holds {
+		byKey[hold.Key] = append(byKey[hold.Key], hold)
+	}
+
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var violations []Violation
+	for _, key := range keys {
+		holds := byKey[key]
+		sort.SliceStable(holds, func(i, j int) bool {
+			return holds[i].Start.Before(holds[j].Start)
+		})
+
+		for i := range holds {
+			for j := i + 1; j < len(holds); j++ {
+				first, second := holds[i], holds[j]
+				switch {
+				case second.Start.Before(first.End):
+					violations = append(violations, Violation{First: first, Second: second, Reason: "holds overlap"})
+				case first.FencingToken != 0 && second.FencingToken != 0 && first.FencingToken >= second.FencingToken:
+					violations = append(violations, Violation{First: first, Second: second, Reason: "fencing token does not increase"})
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// Simulate runs simulated clients competing for a lock until each made its attempts or ctx is done,
+// and returns their history, newClient creates the redislock client of each simulated client.
+// Errors of redis calls are part of the simulation, a client that gets one does not hold the lock.
+// The history and the hold times are measured by the clock of WithSimulationClock, so the clients must use it too.
+func Simulate(ctx context.Context, newClient func(id int) (*redislock.Client, error), options ...SimulationOption) (*History, error) {
+	s := &simulation{
+		clients:    8,
+		attempts:   50,
+		key:        "redislocktest:simulate",
+		expiration: 100 * time.Millisecond,
+		holdTime:   10 * time.Millisecond,
+		seed:       time.Now().UnixNano(),
+		clock:      systemClock{},
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	clients := make([]*redislock.Client, s.clients)
+	for id := range clients {
+		client, err := newClient(id)
+		if err != nil {
+			return nil, fmt.Errorf("newClient error: %w", err)
+		}
+		clients[id] = client
+	}
+
+	history := NewHistory(WithHistoryClock(s.clock))
+	var wg sync.WaitGroup
+	for id, client := range clients {
+		wg.Add(1)
+		go func(id int, client *redislock.Client, random *rand.Rand) {
+			defer wg.Done()
+			s.run(ctx, id, client, history, random)
+		}(id, client, rand.New(rand.NewSource(s.seed+int64(id))))
+	}
+	wg.Wait()
+
+	return history, nil
+}
+
+type simulation struct {
+	clients    int
+	attempts   int
+	key        string
+	expiration time.Duration
+	holdTime   time.Duration
+	seed       int64
+	clock      redislock.Clock
+	watchDog   bool // clients keep their locks by a watch dog.
+}
+
+type SimulationOption func(simulation *simulation)
+
+// WithClients sets the count of simulated clients, default is 8.
+func WithClients(clients int) SimulationOption {
+	return func(simulation *simulation) {
+		simulation.clients = clients
+	}
+}
+
+// WithAttempts sets the count of acquire attempts of each client, default is 50.
+func WithAttempts(attempts int) SimulationOption {
+	return func(simulation *simulation) {
+		simulation.attempts = attempts
+	}
+}
+
+// WithKey sets the key of the lock, default is "redislocktest:simulate".
+func WithKey(key string) SimulationOption {
+	return func(simulation *simulation) {
+		simulation.key = key
+	}
+}
+
+// WithLockExpiration sets the expiration of the lock, default is 100ms.
+func WithLockExpiration(expiration time.Duration) SimulationOption {
+	return func(simulation *simulation) {
+		simulation.expiration = expiration
+	}
+}
+
+// WithHoldTime sets the longest time a client holds the lock, the time is random up to holdTime, default is 10ms.
+// A hold time longer than the expiration makes holders outlive their locks, unless WithWatchDog keeps them.
+func WithHoldTime(holdTime time.Duration) SimulationOption {
+	return func(simulation *simulation) {
+		simulation.holdTime = holdTime
+	}
+}
+
+// WithSimulationSeed seeds the random hold times of the clients.
+func WithSimulationSeed(seed int64) SimulationOption {
+	return func(simulation *simulation) {
+		simulation.seed = seed
+	}
+}
+
+// WithSimulationClock sets the clock measuring the history and the hold times, default is the system clock.
+func WithSimulationClock(clock redislock.Clock) SimulationOption {
+	return func(simulation *simulation) {
+		simulation.clock = clock
+	}
+}
+
+// WithWatchDog makes clients keep their locks by a watch dog of the lock expiration,
+// so holds may last longer than the expiration, a client stops holding once its lock is lost.
+func WithWatchDog() SimulationOption {
+	return func(simulation *simulation) {
+		simulation.watchDog = true
+	}
+}
+
+func (s *simulation) run(ctx context.Context, id int, client *redislock.Client, history *History, random *rand.Rand) {
+	for attempt := 0; attempt < s.attempts && ctx.Err() == nil; attempt++ {
+		mutex, err := s.lock(ctx, client)
+		if err != nil {
+			s.sleep(ctx, random, nil)
+			continue
+		}
+		history.Acquired(id, mutex)
+
+		s.sleep(ctx, random, mutex.Lost())
+
+		history.Released(id, mutex)
+		_ = mutex.Unlock(ctx)
+	}
+}
+
+func (s *simulation) lock(ctx context.Context, client *redislock.Client) (*redislock.Mutex, error) {
+	if s.watchDog {
+		return client.TryLockWithWatchDog(ctx, s.key, redislock.NewWatchDog(s.expiration))
+	}
+	return client.TryLock(ctx, s.key, s.expiration)
+}
+
+// sleep waits for a random time up to the hold time, until ctx is done or lost is closed.
+func (s *simulation) sleep(ctx context.Context, random *rand.Rand, lost <-chan struct{}) {
+	if s.holdTime <= 0 {
+		return
+	}
+
+	timer, stop := s.clock.NewTimer(time.Duration(random.Int63n(int64(s.holdTime) + 1)))
+	defer stop()
+
+	select {
+	case <-ctx.Done():
+	case <-lost:
+	case <-timer:
+	}
+}
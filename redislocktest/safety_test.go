@@ -0,0 +1,211 @@
+package redislocktest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/XdpCs/redis-lock/redislocktest"
+)
+
+func TestCheckHolds(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	at := func(ms int) time.Time {
+		return start.Add(time.Duration(ms) * time.Millisecond)
+	}
+
+	// test cases
+	tests := []struct {
+		Name     string
+		Holds    []redislocktest.Hold
+		Expected []string // reasons of the violations.
+	}{
+		{
+			"Sequential",
+			[]redislocktest.Hold{
+				{Client: 1, Key: "test", FencingToken: 1, Start: at(0), End: at(10)},
+				{Client: 2, Key: "test", FencingToken: 2, Start: at(10), End: at(20)},
+			},
+			nil,
+		},
+		{
+			"Overlap",
+			[]redislocktest.Hold{
+				{Client: 1, Key: "test", Start: at(0), End: at(10)},
+				{Client: 2, Key: "test", Start: at(5), End: at(20)},
+			},
+			[]string{"holds overlap"},
+		},
+		{
+			"OverlapOtherKey",
+			[]redislocktest.Hold{
+				{Client: 1, Key: "test", Start: at(0), End: at(10)},
+				{Client: 2, Key: "other", Start: at(5), End: at(20)},
+			},
+			nil,
+		},
+		{
+			"FencingTokenDecrease",
+			[]redislocktest.Hold{
+				{Client: 1, Key: "test", FencingToken: 2, Start: at(0), End: at(10)},
+				{Client: 2, Key: "test", FencingToken: 1, Start: at(20), End: at(30)},
+			},
+			[]string{"fencing token does not increase"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			violations := redislocktest.CheckHolds(tc.Holds)
+			if len(violations) != len(tc.Expected) {
+				t.Fatalf("violations is not equal,expected %v, got %v", tc.Expected, violations)
+			}
+			for i, violation := range violations {
+				if violation.Reason != tc.Expected[i] {
+					t.Fatalf("Reason is not equal,expected %v, got %v", tc.Expected[i], violation.Reason)
+				}
+			}
+		})
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+
+	// test cases
+	tests := []struct {
+		Name      string
+		NewClient func(id int) (*redislock.Client, error)
+		Options   []redislocktest.SimulationOption
+		Longer    time.Duration // some hold lasts longer than it, if it is not zero.
+	}{
+		{
+			"Fencing",
+			func(int) (*redislock.Client, error) {
+				return redislock.NewClient(rdb, redislock.WithFencing())
+			},
+			[]redislocktest.SimulationOption{redislocktest.WithKey("fencing")},
+			0,
+		},
+		{
+			// holders outlive their locks, their holds end at the expiration.
+			"Expiration",
+			func(int) (*redislock.Client, error) {
+				return redislock.NewClient(rdb)
+			},
+			[]redislocktest.SimulationOption{
+				redislocktest.WithKey("expiration"),
+				redislocktest.WithLockExpiration(5 * time.Millisecond),
+				redislocktest.WithHoldTime(10 * time.Millisecond),
+			},
+			0,
+		},
+		{
+			"Faults",
+			func(id int) (*redislock.Client, error) {
+				faulty := redislocktest.NewFaultyClient(rdb, redislocktest.WithSeed(int64(id)))
+				faulty.Inject(redislocktest.CommandAll, redislocktest.Probability(0.2, redislocktest.Fault{Drop: true}))
+				return redislock.NewClient(faulty, redislock.WithFencing())
+			},
+			[]redislocktest.SimulationOption{redislocktest.WithKey("faults")},
+			0,
+		},
+		{
+			// watch dogs keep the locks of holders longer than the expiration.
+			"WatchDog",
+			func(int) (*redislock.Client, error) {
+				return redislock.NewClient(rdb, redislock.WithFencing())
+			},
+			[]redislocktest.SimulationOption{
+				redislocktest.WithKey("watchDog"),
+				redislocktest.WithAttempts(5),
+				redislocktest.WithWatchDog(),
+				redislocktest.WithLockExpiration(30 * time.Millisecond),
+				redislocktest.WithHoldTime(100 * time.Millisecond),
+			},
+			30 * time.Millisecond,
+		},
+		{
+			// watch dogs fail to refresh the locks, holders stop once their locks are lost.
+			"WatchDogFaults",
+			func(id int) (*redislock.Client, error) {
+				faulty := redislocktest.NewFaultyClient(rdb, redislocktest.WithSeed(int64(id)))
+				faulty.Inject(redislocktest.CommandRefresh, redislocktest.Probability(0.3, redislocktest.Fault{Drop: true}))
+				return redislock.NewClient(faulty, redislock.WithFencing())
+			},
+			[]redislocktest.SimulationOption{
+				redislocktest.WithKey("watchDogFaults"),
+				redislocktest.WithAttempts(5),
+				redislocktest.WithWatchDog(),
+				redislocktest.WithLockExpiration(30 * time.Millisecond),
+				redislocktest.WithHoldTime(100 * time.Millisecond),
+			},
+			0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			options := append([]redislocktest.SimulationOption{redislocktest.WithClients(4), redislocktest.WithAttempts(20)}, tc.Options...)
+			history, err := redislocktest.Simulate(context.Background(), tc.NewClient, options...)
+			if err != nil {
+				t.Fatalf("Simulate error:[%v]", err)
+			}
+
+			holds := history.Holds()
+			if len(holds) == 0 {
+				t.Fatalf("Holds is empty")
+			}
+			if tc.Longer != 0 {
+				longest := time.Duration(0)
+				for _, hold := range holds {
+					if d := hold.End.Sub(hold.Start); d > longest {
+						longest = d
+					}
+				}
+				if longest <= tc.Longer {
+					t.Errorf("longest hold is not longer than %v, got %v", tc.Longer, longest)
+				}
+			}
+			for _, violation := range history.Check() {
+				t.Errorf("violation: %v", violation)
+			}
+		})
+	}
+}
+
+func TestSimulate_Clock(t *testing.T) {
+	clock := redislocktest.NewFakeClock(time.Unix(1700000000, 0))
+	rdb := redislocktest.NewRedis(redislocktest.WithClock(clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// the clients hold the lock until the clock is advanced.
+	go func() {
+		for ctx.Err() == nil {
+			clock.Advance(time.Millisecond)
+			time.Sleep(10 * time.Microsecond)
+		}
+	}()
+
+	history, err := redislocktest.Simulate(ctx, func(int) (*redislock.Client, error) {
+		return redislock.NewClient(rdb, redislock.WithFencing(), redislock.WithClock(clock))
+	}, redislocktest.WithClients(4), redislocktest.WithAttempts(20), redislocktest.WithSimulationClock(clock))
+	if err != nil {
+		t.Fatalf("Simulate error:[%v]", err)
+	}
+
+	holds := history.Holds()
+	if len(holds) == 0 {
+		t.Fatalf("Holds is empty")
+	}
+	for _, hold := range holds {
+		if hold.Start.Before(time.Unix(1700000000, 0)) || hold.Start.After(clock.Now()) {
+			t.Fatalf("hold is not measured by the clock, got %v", hold.Start)
+		}
+	}
+	for _, violation := range history.Check() {
+		t.Errorf("violation: %v", violation)
+	}
+}
@@ -0,0 +1,42 @@
+package redislocktest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/XdpCs/redis-lock/scheduler"
+)
+
+func TestScheduler_WithClock(t *testing.T) {
+	client, clock := newFakeClockClient(t)
+	s := scheduler.New(client)
+
+	ticks := make(chan time.Time)
+	s.Add("testJob", scheduler.Every(time.Minute), func(ctx context.Context, tick time.Time) error {
+		ticks <- tick
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+
+	next := clock.Now().Truncate(time.Minute).Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		// the scheduler waits for the next tick on the clock of the client.
+		clock.WaitForTimers(1)
+		clock.Advance(next.Sub(clock.Now()))
+
+		if tick := <-ticks; !tick.Equal(next) {
+			t.Fatalf("tick is not equal,expected %v, got %v", next, tick)
+		}
+		next = next.Add(time.Minute)
+	}
+
+	clock.WaitForTimers(1)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Run error is not equal,expected %v, got %v", context.Canceled, err)
+	}
+}
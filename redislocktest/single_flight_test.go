@@ -0,0 +1,112 @@
+package redislocktest_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/XdpCs/redis-lock/redislocktest"
+)
+
+func TestSingleFlight_Do_LockLost(t *testing.T) {
+	client, clock := newFakeClockClient(t)
+	s := client.NewSingleFlight()
+	ctx := context.Background()
+
+	called := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Do(ctx, "test", func(ctx context.Context) ([]byte, error) {
+			close(called)
+			// the ctx of fn is done once the lock expires.
+			<-ctx.Done()
+			return []byte("value"), nil
+		})
+		done <- err
+	}()
+
+	<-called
+	clock.WaitForTimers(1)
+	clock.Advance(redislock.DefaultExpiration)
+
+	if err := <-done; !redislock.IsMutexNotHeld(err) {
+		t.Fatalf("Do error is not equal,expected %v, got %v", redislock.ErrMutexNotHeld, err)
+	}
+
+	// the result of the caller which lost the lock is not published, so fn is called again.
+	value, err := s.Do(ctx, "test", func(ctx context.Context) ([]byte, error) {
+		return []byte("again"), nil
+	})
+	if err != nil {
+		t.Fatalf("Do error:[%v]", err)
+	}
+	if string(value) != "again" {
+		t.Fatalf("value is not equal,expected %v, got %v", "again", string(value))
+	}
+}
+
+// subscribeCounter is a testBackend which counts the subscriptions.
+type subscribeCounter struct {
+	testBackend
+	subscriptions *int32
+}
+
+func (b subscribeCounter) Subscribe(ctx context.Context, channel string) (<-chan struct{}, func(), error) {
+	atomic.AddInt32(b.subscriptions, 1)
+	return b.testBackend.Subscribe(ctx, channel)
+}
+
+func TestSingleFlight_Do_SubscribeOnlyToWait(t *testing.T) {
+	var subscriptions int32
+	client, err := redislock.NewClientWithBackend(subscribeCounter{testBackend{client: redislocktest.NewRedis()}, &subscriptions})
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+	s := client.NewSingleFlight()
+	ctx := context.Background()
+
+	// the caller calling fn and the callers finding its result do not subscribe.
+	for i := 0; i < 3; i++ {
+		value, err := s.Do(ctx, "test", func(ctx context.Context) ([]byte, error) {
+			return []byte("value"), nil
+		})
+		if err != nil {
+			t.Fatalf("Do error:[%v]", err)
+		}
+		if string(value) != "value" {
+			t.Fatalf("value is not equal,expected %v, got %v", "value", string(value))
+		}
+	}
+	if actual := atomic.LoadInt32(&subscriptions); actual != 0 {
+		t.Fatalf("subscriptions is not equal,expected %v, got %v", 0, actual)
+	}
+}
+
+func TestSingleFlight_Do_UnlockAfterCancel(t *testing.T) {
+	rdb := redislocktest.NewRedis()
+	faulty := redislocktest.NewFaultyClient(rdb)
+	// the unlock fails at once with a done ctx.
+	faulty.Inject(redislocktest.CommandUnlock, redislocktest.Always(redislocktest.Fault{Latency: time.Millisecond}))
+	client, err := redislock.NewClient(faulty)
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+	s := client.NewSingleFlight()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err = s.Do(ctx, "test", func(context.Context) ([]byte, error) {
+		cancel()
+		return nil, errors.New("cancelled")
+	})
+	if err == nil {
+		t.Fatalf("Do is not equal,expected an error, got nil")
+	}
+
+	// the lock is released, so the next caller does not wait for its expiration.
+	if exists, _ := rdb.Do("exists", "{test}:lock"); exists != int64(0) {
+		t.Fatalf("Exists is not equal,expected %v, got %v", 0, exists)
+	}
+}
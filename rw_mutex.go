@@ -0,0 +1,227 @@
+package redislock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RWMutex is a distributed read-write lock based on redis,
+// many readers or one writer hold it at a time.
+// A reader can be upgraded to the writer and the writer can be downgraded to a reader.
+type RWMutex struct {
+	client     *Client
+	key        string
+	value      string
+	expiration time.Duration
+
+	mu     sync.Mutex // guards writer.
+	writer bool       // the mutex holds the write lock or a read lock.
+}
+
+// TryReadLock tries to acquire a read lock with retry strategy,
+// it fails while the write lock is held or a reader is upgrading.
+// Each read lock expires on its own, a reader not refreshing its lock does not block writers
+// after its expiration, even if other readers keep refreshing theirs.
+func (c *Client) TryReadLock(ctx context.Context, key string, expiration time.Duration, retryStrategy RetryStrategy) (*RWMutex, error) {
+	return c.tryRWLock(ctx, key, expiration, retryStrategy, false)
+}
+
+// TryWriteLock tries to acquire the write lock with retry strategy,
+// it fails while any lock is held.
+func (c *Client) TryWriteLock(ctx context.Context, key string, expiration time.Duration, retryStrategy RetryStrategy) (*RWMutex, error) {
+	return c.tryRWLock(ctx, key, expiration, retryStrategy, true)
+}
+
+func (c *Client) tryRWLock(ctx context.Context, key string, expiration time.Duration, retryStrategy RetryStrategy, writer bool) (*RWMutex, error) {
+	value, err := c.getValue()
+	if err != nil {
+		return nil, fmt.Errorf("c.getValue error: %w", err)
+	}
+
+	script := luaReadLock
+	if writer {
+		script = luaWriteLock
+	}
+
+	unlock := luaReadUnlock
+	if writer {
+		unlock = luaWriteUnlock
+	}
+
+	keys := rwKeys(key)
+	_, err = c.retryLock(ctx, key, value, expiration, retryStrategy, func(ctx context.Context) (bool, error) {
+		cmd, waitErr := c.evalWait(ctx, true, script, keys, value, expiration.Milliseconds())
+		status, err := cmd.Int()
+		if err != nil || status != 1 {
+			return false, err
+		}
+		if waitErr != nil {
+			c.rollback(unlock, keys, value)
+			return false, waitErr
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &RWMutex{
+		client:     c,
+		key:        key,
+		value:      value,
+		expiration: expiration,
+		writer:     writer,
+	}, nil
+}
+
+// Key returns the key of the lock.
+func (rw *RWMutex) Key() string {
+	return rw.key
+}
+
+// IsWriter returns true if the mutex holds the write lock.
+func (rw *RWMutex) IsWriter() bool {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.writer
+}
+
+// Unlock releases the read lock or the write lock held by the mutex.
+func (rw *RWMutex) Unlock(ctx context.Context) error {
+	if rw == nil {
+		return ErrMutexNotInitialized
+	}
+
+	script := luaReadUnlock
+	if rw.IsWriter() {
+		script = luaWriteUnlock
+	}
+
+	status, err := rw.client.eval(ctx, script, rwKeys(rw.key), rw.value).Int()
+	if err != nil {
+		return err
+	}
+
+	if status != 1 {
+		return ErrMutexNotHeld
+	}
+	return nil
+}
+
+// Refresh resets the lock's expiration if it is shorter than the expiration of the mutex,
+// returns ErrMutexNotHeld if the lock is no longer held.
+func (rw *RWMutex) Refresh(ctx context.Context) error {
+	if rw == nil {
+		return ErrMutexNotHeld
+	}
+
+	cmd, waitErr := rw.client.evalWait(ctx, rw.client.waitRefresh, luaRWRefresh, rwKeys(rw.key), rw.value, rw.expiration.Milliseconds())
+	status, err := cmd.Int()
+	if err != nil {
+		return err
+	}
+
+	if status != 1 {
+		return ErrMutexNotHeld
+	}
+	return waitErr
+}
+
+// Upgrade turns the read lock into the write lock without releasing it,
+// waiting for other readers to drain as retryStrategy tells, new readers are blocked meanwhile.
+// Only one reader can upgrade at a time, the others get ErrRWMutexUpgradeConflict at once
+// and should release their read locks, otherwise the upgrade waits for them until their read locks expire.
+// If ctx has no deadline, the upgrade gives up after the expiration of the mutex.
+// If the upgrade fails, the mutex still holds the read lock. With WithWait, the write lock is
+// turned back into the read lock if too few replicas acknowledge it, and ErrNotEnoughReplicas is returned.
+func (rw *RWMutex) Upgrade(ctx context.Context, retryStrategy RetryStrategy) error {
+	if rw == nil {
+		return ErrMutexNotHeld
+	}
+
+	if rw.IsWriter() {
+		return nil
+	}
+
+	keys := rwKeys(rw.key)
+	_, err := rw.client.retryLock(ctx, rw.key, rw.value, rw.expiration, retryStrategy, func(ctx context.Context) (bool, error) {
+		cmd, waitErr := rw.client.evalWait(ctx, true, luaUpgrade, keys, rw.value, rw.expiration.Milliseconds())
+		status, err := cmd.Int()
+		if err != nil {
+			return false, err
+		}
+
+		switch status {
+		case -1:
+			return false, ErrMutexNotHeld
+		case -2:
+			return false, ErrRWMutexUpgradeConflict
+		case 1:
+			if waitErr != nil {
+				rw.client.rollback(luaDowngrade, keys, rw.value, rw.expiration.Milliseconds())
+				return false, waitErr
+			}
+		}
+		return status == 1, nil
+	})
+	if err != nil {
+		if !IsMutexNotHeld(err) && !IsRWMutexUpgradeConflict(err) {
+			rw.cancelUpgrade(ctx)
+		}
+		return err
+	}
+
+	rw.mu.Lock()
+	rw.writer = true
+	rw.mu.Unlock()
+	return nil
+}
+
+// cancelUpgrade unblocks new readers after a failed upgrade.
+func (rw *RWMutex) cancelUpgrade(ctx context.Context) {
+	if ctx.Err() != nil {
+		// cancel the upgrade even if the caller has given up, bounded so a hung connection does not block.
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), ReleaseTimeout)
+		defer cancel()
+	}
+
+	if err := rw.client.eval(ctx, luaCancelUpgrade, rwKeys(rw.key), rw.value).Err(); err != nil {
+		rw.client.logger.Error("redislock: cancel upgrade failed", "key", rw.key, "token", logToken(rw.value), "error", err)
+	}
+}
+
+// Downgrade turns the write lock into a read lock without releasing it,
+// so other readers can acquire the lock. It does not wait for the replicas with WithWait,
+// since a downgrade lost in a failover leaves the stricter write lock of the same holder.
+func (rw *RWMutex) Downgrade(ctx context.Context) error {
+	if rw == nil {
+		return ErrMutexNotHeld
+	}
+
+	if !rw.IsWriter() {
+		return nil
+	}
+
+	status, err := rw.client.eval(ctx, luaDowngrade, rwKeys(rw.key), rw.value, rw.expiration.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+
+	if status != 1 {
+		return ErrMutexNotHeld
+	}
+
+	rw.mu.Lock()
+	rw.writer = false
+	rw.mu.Unlock()
+	return nil
+}
+
+// rwKeys returns the keys of the read-write lock of key: the hash of the lock
+// and the sorted set of the readers, which is in the same redis cluster hash slot.
+func rwKeys(key string) []string {
+	return []string{key, sameSlotKey(key, ":readers")}
+}
@@ -0,0 +1,114 @@
+package redislock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestClient_TryReadLock(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	// init redislock client
+	client, err := NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+	key := "testOne"
+	defer teardown(t, rdb, rwKeys(key))
+
+	ctx := context.Background()
+
+	readerOne, err := client.TryReadLock(ctx, key, 10*time.Second, NewNoRetry())
+	if err != nil {
+		t.Fatalf("readerOne TryReadLock error:[%v]", err)
+	}
+
+	readerTwo, err := client.TryReadLock(ctx, key, 10*time.Second, NewNoRetry())
+	if err != nil {
+		t.Fatalf("readerTwo TryReadLock error:[%v]", err)
+	}
+
+	t.Run("TryWriteLockWhileReading", func(t *testing.T) {
+		if _, err := client.TryWriteLock(ctx, key, 10*time.Second, NewNoRetry()); !IsMutexLockFailed(err) {
+			t.Errorf("TryWriteLock error is not equal,expected %v, got %v", ErrMutexLockFailed, err)
+		}
+	})
+
+	t.Run("UpgradeConflict", func(t *testing.T) {
+		// readerOne waits for readerTwo to drain.
+		upgraded := make(chan error, 1)
+		go func() {
+			upgraded <- readerOne.Upgrade(ctx, NewAverageRetry(100, 10*time.Millisecond))
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		if err := readerTwo.Upgrade(ctx, NewNoRetry()); !IsRWMutexUpgradeConflict(err) {
+			t.Errorf("readerTwo Upgrade error is not equal,expected %v, got %v", ErrRWMutexUpgradeConflict, err)
+		}
+
+		if _, err := client.TryReadLock(ctx, key, 10*time.Second, NewNoRetry()); !IsMutexLockFailed(err) {
+			t.Errorf("TryReadLock error while upgrading is not equal,expected %v, got %v", ErrMutexLockFailed, err)
+		}
+
+		if err := readerTwo.Unlock(ctx); err != nil {
+			t.Fatalf("readerTwo Unlock error:[%v]", err)
+		}
+
+		if err := <-upgraded; err != nil {
+			t.Fatalf("readerOne Upgrade error:[%v]", err)
+		}
+
+		if !readerOne.IsWriter() {
+			t.Errorf("readerOne is not the writer after upgrade")
+		}
+	})
+
+	t.Run("Downgrade", func(t *testing.T) {
+		if err := readerOne.Downgrade(ctx); err != nil {
+			t.Fatalf("Downgrade error:[%v]", err)
+		}
+
+		if readerOne.IsWriter() {
+			t.Errorf("readerOne is the writer after downgrade")
+		}
+
+		readerThree, err := client.TryReadLock(ctx, key, 10*time.Second, NewNoRetry())
+		if err != nil {
+			t.Fatalf("readerThree TryReadLock error:[%v]", err)
+		}
+
+		if err = readerThree.Unlock(ctx); err != nil {
+			t.Fatalf("readerThree Unlock error:[%v]", err)
+		}
+	})
+
+	t.Run("UnlockAll", func(t *testing.T) {
+		if err := readerOne.Unlock(ctx); err != nil {
+			t.Fatalf("readerOne Unlock error:[%v]", err)
+		}
+
+		if err := readerOne.Unlock(ctx); !IsMutexNotHeld(err) {
+			t.Errorf("Unlock error is not equal,expected %v, got %v", ErrMutexNotHeld, err)
+		}
+
+		writer, err := client.TryWriteLock(ctx, key, 10*time.Second, NewNoRetry())
+		if err != nil {
+			t.Fatalf("TryWriteLock error:[%v]", err)
+		}
+
+		if err = writer.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh error:[%v]", err)
+		}
+
+		if err = writer.Unlock(ctx); err != nil {
+			t.Fatalf("writer Unlock error:[%v]", err)
+		}
+	})
+}
@@ -0,0 +1,30 @@
+package scheduler
+
+import "time"
+
+// Schedule tells the ticks of a job, every replica must compute the same ticks,
+// the Schedule of github.com/robfig/cron satisfies it.
+type Schedule interface {
+	// Next returns the first tick after t.
+	Next(t time.Time) time.Time
+}
+
+// everySchedule ticks at every multiple of interval since the unix epoch.
+type everySchedule struct {
+	interval time.Duration
+}
+
+// Every returns a Schedule ticking at every multiple of interval since the unix epoch,
+// so replicas started at different times share the same ticks.
+// Like time.NewTicker, it panics if interval is not positive.
+func Every(interval time.Duration) Schedule {
+	if interval <= 0 {
+		panic("scheduler: non-positive interval for Every")
+	}
+	return &everySchedule{interval: interval}
+}
+
+// Next returns the first multiple of interval after t.
+func (e *everySchedule) Next(t time.Time) time.Time {
+	return t.Truncate(e.interval).Add(e.interval)
+}
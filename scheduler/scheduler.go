@@ -0,0 +1,236 @@
+// Package scheduler runs scheduled jobs on many replicas, each tick of a job is run once fleet-wide.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+)
+
+const (
+	// DefaultKeyPrefix is the default prefix of the tick lock keys.
+	DefaultKeyPrefix = "redislock:scheduler:"
+	// DefaultTimeout is the default timeout of a job run, which is the expiration of the tick lock.
+	DefaultTimeout = time.Minute
+	// DefaultRetention is the default time a completed tick is recorded, late replicas skip it meanwhile.
+	DefaultRetention = 24 * time.Hour
+)
+
+// MissedRunPolicy tells what to do with the ticks missed by a replica,
+// because it is started late or a job run outlasts the next tick.
+type MissedRunPolicy int
+
+const (
+	// SkipMissed skips missed ticks and waits for the next tick.
+	SkipMissed MissedRunPolicy = iota
+	// RunLatestMissed runs the latest missed tick only.
+	RunLatestMissed
+	// RunAllMissed runs every missed tick in order.
+	RunAllMissed
+)
+
+// Job is a scheduled job, tick is the scheduled time of the run.
+type Job func(ctx context.Context, tick time.Time) error
+
+// Scheduler runs jobs on their schedules, a tick of a job is run by the replica acquiring its tick lock,
+// the lock is kept after a successful run to record the completion, so late replicas skip the tick.
+type Scheduler struct {
+	client    *redislock.Client
+	keyPrefix string
+	onError   func(name string, tick time.Time, err error) // called when a run fails.
+
+	mu   sync.Mutex
+	jobs []*job
+}
+
+type job struct {
+	name       string
+	schedule   Schedule
+	run        Job
+	timeout    time.Duration
+	retention  time.Duration
+	policy     MissedRunPolicy
+	catchUpFor time.Duration // ticks in the window before the start are missed.
+}
+
+// New creates a new Scheduler.
+func New(client *redislock.Client, options ...Option) *Scheduler {
+	s := &Scheduler{client: client, keyPrefix: DefaultKeyPrefix}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+type Option func(scheduler *Scheduler)
+
+// WithKeyPrefix sets the prefix of the tick lock keys.
+func WithKeyPrefix(keyPrefix string) Option {
+	return func(scheduler *Scheduler) {
+		scheduler.keyPrefix = keyPrefix
+	}
+}
+
+// WithOnError sets the callback called when acquiring a tick lock, running a job or recording its completion fails.
+func WithOnError(onError func(name string, tick time.Time, err error)) Option {
+	return func(scheduler *Scheduler) {
+		scheduler.onError = onError
+	}
+}
+
+type JobOption func(job *job)
+
+// WithTimeout sets the timeout of a run, which is the expiration of the tick lock.
+func WithTimeout(timeout time.Duration) JobOption {
+	return func(job *job) {
+		job.timeout = timeout
+	}
+}
+
+// WithRetention sets how long a completed tick is recorded, it should be longer than the
+// clock skew between replicas and the catch up window.
+func WithRetention(retention time.Duration) JobOption {
+	return func(job *job) {
+		job.retention = retention
+	}
+}
+
+// WithMissedRunPolicy sets the policy of missed ticks, ticks within catchUpFor before
+// the start of the scheduler are missed too.
+func WithMissedRunPolicy(policy MissedRunPolicy, catchUpFor time.Duration) JobOption {
+	return func(job *job) {
+		job.policy = policy
+		job.catchUpFor = catchUpFor
+	}
+}
+
+// Add adds a job named name, the name must be the same on every replica.
+func (s *Scheduler) Add(name string, schedule Schedule, run Job, options ...JobOption) {
+	j := &job{
+		name:      name,
+		schedule:  schedule,
+		run:       run,
+		timeout:   DefaultTimeout,
+		retention: DefaultRetention,
+		policy:    SkipMissed,
+	}
+
+	for _, option := range options {
+		option(j)
+	}
+
+	s.mu.Lock()
+	s.jobs = append(s.jobs, j)
+	s.mu.Unlock()
+}
+
+// Run runs the jobs until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	jobs := append([]*job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		wg.Add(1)
+		go func(j *job) {
+			defer wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// loop runs the ticks of j until ctx is done.
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	clock := s.client.Clock()
+	last := clock.Now().Add(-j.catchUpFor)
+	for {
+		now := clock.Now()
+		for _, tick := range missedTicks(j.schedule, j.policy, last, now) {
+			s.runTick(ctx, j, tick)
+		}
+
+		next := j.schedule.Next(now)
+		last = now
+
+		timer, stop := clock.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			stop()
+			return
+		case <-timer:
+		}
+
+		s.runTick(ctx, j, next)
+		last = next
+	}
+}
+
+// missedTicks returns the ticks in (last, now] to run according to policy,
+// it stops at a tick that is not after the previous one, so a broken Schedule can not loop forever.
+func missedTicks(schedule Schedule, policy MissedRunPolicy, last, now time.Time) []time.Time {
+	if policy == SkipMissed {
+		return nil
+	}
+
+	var ticks []time.Time
+	for prev, tick := last, schedule.Next(last); tick.After(prev) && !tick.After(now); prev, tick = tick, schedule.Next(tick) {
+		ticks = append(ticks, tick)
+	}
+
+	if policy == RunLatestMissed && len(ticks) > 1 {
+		ticks = ticks[len(ticks)-1:]
+	}
+	return ticks
+}
+
+// runTick runs tick of j if this replica wins the tick lock,
+// it returns true if the job is run by this replica.
+func (s *Scheduler) runTick(ctx context.Context, j *job, tick time.Time) bool {
+	mutex, err := s.client.TryLock(ctx, s.TickKey(j.name, tick), j.timeout)
+	if redislock.IsMutexLockFailed(err) {
+		// the tick is run or completed by another replica.
+		return false
+	} else if err != nil {
+		s.handleError(j.name, tick, fmt.Errorf("TryLock error: %w", err))
+		return false
+	}
+
+	runCtx, cancel := s.client.Clock().WithDeadline(ctx, mutex.Deadline())
+	defer cancel()
+
+	if err = j.run(runCtx, tick); err != nil {
+		s.handleError(j.name, tick, err)
+		// release the tick, so late replicas can run it.
+		if err = mutex.Unlock(ctx); err != nil {
+			s.handleError(j.name, tick, fmt.Errorf("Unlock error: %w", err))
+		}
+		return true
+	}
+
+	// keep the tick lock to record the completion.
+	if _, err = mutex.Extend(ctx, j.retention); err != nil {
+		s.handleError(j.name, tick, fmt.Errorf("Extend error: %w", err))
+	}
+	return true
+}
+
+// TickKey returns the key of the tick lock of the job named name.
+func (s *Scheduler) TickKey(name string, tick time.Time) string {
+	return s.keyPrefix + name + ":" + strconv.FormatInt(tick.UnixMilli(), 10)
+}
+
+func (s *Scheduler) handleError(name string, tick time.Time, err error) {
+	if s.onError != nil {
+		s.onError(name, tick, err)
+	}
+}
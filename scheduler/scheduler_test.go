@@ -0,0 +1,198 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	redislock "github.com/XdpCs/redis-lock"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestEvery(t *testing.T) {
+	base := time.Date(2023, 8, 29, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{"OnTick", base, base.Add(time.Minute)},
+		{"BetweenTicks", base.Add(30 * time.Second), base.Add(time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Every(time.Minute).Next(tt.t); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvery_NonPositive(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Minute} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Every(%v) does not panic", interval)
+				}
+			}()
+			Every(interval)
+		}()
+	}
+}
+
+// stuckSchedule always ticks at the same time.
+type stuckSchedule time.Time
+
+func (s stuckSchedule) Next(t time.Time) time.Time {
+	return time.Time(s)
+}
+
+func TestMissedTicks_StuckSchedule(t *testing.T) {
+	base := time.Date(2023, 8, 29, 10, 0, 0, 0, time.UTC)
+	got := missedTicks(stuckSchedule(base.Add(time.Minute)), RunAllMissed, base, base.Add(time.Hour))
+	if len(got) != 1 || !got[0].Equal(base.Add(time.Minute)) {
+		t.Fatalf("missedTicks() = %v, want %v", got, []time.Time{base.Add(time.Minute)})
+	}
+}
+
+func TestMissedTicks(t *testing.T) {
+	base := time.Date(2023, 8, 29, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		policy MissedRunPolicy
+		want   []time.Time
+	}{
+		{"SkipMissed", SkipMissed, nil},
+		{"RunLatestMissed", RunLatestMissed, []time.Time{base.Add(3 * time.Minute)}},
+		{"RunAllMissed", RunAllMissed, []time.Time{base.Add(time.Minute), base.Add(2 * time.Minute), base.Add(3 * time.Minute)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := missedTicks(Every(time.Minute), tt.policy, base, base.Add(3*time.Minute+30*time.Second))
+			if len(got) != len(tt.want) {
+				t.Fatalf("missedTicks() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("missedTicks() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduler_runTick(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	// init redislock client
+	client, err := redislock.NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+
+	var errs []error
+	s := New(client, WithOnError(func(name string, tick time.Time, err error) {
+		errs = append(errs, err)
+	}))
+
+	tick := time.Date(2023, 8, 29, 10, 0, 0, 0, time.UTC)
+	errJob := errors.New("job failed")
+	failed := &job{name: "testJob", run: func(ctx context.Context, tick time.Time) error { return errJob }, timeout: time.Second, retention: time.Minute}
+	succeeded := &job{name: "testJob", run: func(ctx context.Context, tick time.Time) error { return nil }, timeout: time.Second, retention: time.Minute}
+	defer rdb.Del(context.Background(), s.TickKey("testJob", tick))
+
+	ctx := context.Background()
+
+	// test cases
+	cases := []struct {
+		Name string
+		Job  *job
+		Ran  bool
+	}{
+		{"FailedRunReleasesTick", failed, true},
+		{"SucceededRun", succeeded, true},
+		{"CompletedTickSkipped", succeeded, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			if ran := s.runTick(ctx, c.Job, tick); ran != c.Ran {
+				t.Errorf("ran is not equal,expected %v, got %v", c.Ran, ran)
+			}
+		})
+	}
+
+	if len(errs) != 1 || !errors.Is(errs[0], errJob) {
+		t.Errorf("errors is not equal,expected %v, got %v", []error{errJob}, errs)
+	}
+
+	ttl, err := rdb.PTTL(ctx, s.TickKey("testJob", tick)).Result()
+	if err != nil {
+		t.Fatalf("PTTL error:[%v]", err)
+	}
+
+	if ttl <= time.Second {
+		t.Errorf("completion is not recorded for the retention, ttl %v", ttl)
+	}
+}
+
+func TestScheduler_Run(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+
+	var mu sync.Mutex
+	runs := make(map[time.Time]int)
+	run := func(ctx context.Context, tick time.Time) error {
+		mu.Lock()
+		defer mu.Unlock()
+		runs[tick]++
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 650*time.Millisecond)
+	defer cancel()
+
+	// replicas running the same job
+	var s *Scheduler
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		client, err := redislock.NewClient(rdb)
+		if err != nil {
+			t.Fatalf("NewClient error:[%v]", err)
+		}
+
+		s = New(client, WithKeyPrefix("testScheduler:"))
+		s.Add("testJob", Every(100*time.Millisecond), run, WithRetention(time.Minute))
+
+		wg.Add(1)
+		go func(s *Scheduler) {
+			defer wg.Done()
+			_ = s.Run(ctx)
+		}(s)
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(runs) < 5 {
+		t.Errorf("ticks run is less than expected, got %v", len(runs))
+	}
+
+	for tick, count := range runs {
+		if count != 1 {
+			t.Errorf("tick %v is run %v times", tick, count)
+		}
+		rdb.Del(context.Background(), s.TickKey("testJob", tick))
+	}
+}
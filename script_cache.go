@@ -0,0 +1,101 @@
+package redislock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Warmup loads all scripts of redislock into the script cache of redis,
+// so the first calls after startup do not miss with NOSCRIPT.
+// If the client uses functions, the function library is loaded first, see WithFunctions.
+// It returns ErrScriptLoaderUnsupported if the backend is not a ScriptLoader.
+func (c *Client) Warmup(ctx context.Context) error {
+	if c.functions {
+		caller, ok := c.backend.(FunctionCaller)
+		if !ok {
+			return ErrFunctionsUnsupported
+		}
+		if err := c.loadLibrary(ctx, caller); err != nil {
+			return err
+		}
+	}
+
+	loader, ok := c.backend.(ScriptLoader)
+	if !ok {
+		return ErrScriptLoaderUnsupported
+	}
+	return c.loadScripts(ctx, loader)
+}
+
+// MissingScripts returns the names of the scripts of redislock not in the script cache of redis,
+// scripts go missing after SCRIPT FLUSH, a restart or a failover of redis.
+// It returns ErrScriptLoaderUnsupported if the backend is not a ScriptLoader.
+func (c *Client) MissingScripts(ctx context.Context) ([]string, error) {
+	loader, ok := c.backend.(ScriptLoader)
+	if !ok {
+		return nil, ErrScriptLoaderUnsupported
+	}
+
+	names := scriptNames()
+	all := make([]*Script, len(names))
+	for i, name := range names {
+		all[i] = scripts[name]
+	}
+
+	exists, err := loader.ScriptExists(ctx, all...)
+	if err != nil {
+		return nil, fmt.Errorf("loader.ScriptExists error: %w", err)
+	}
+
+	var missing []string
+	for i, name := range names {
+		if !exists[i] {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+// reloadTimeout bounds the reload of all scripts after a NOSCRIPT error.
+const reloadTimeout = 5 * time.Second
+
+// reloadScripts reloads all scripts in the background after a NOSCRIPT error, so the call which got it
+// does not wait for the reload, a reload in progress is not started again, the callers fall back to EVAL meanwhile.
+func (c *Client) reloadScripts(loader ScriptLoader) {
+	if !atomic.CompareAndSwapInt32(&c.reloading, 0, 1) {
+		return
+	}
+
+	c.logger.Warn("redislock: script cache flushed, reloading scripts")
+	go func() {
+		defer atomic.StoreInt32(&c.reloading, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), reloadTimeout)
+		defer cancel()
+		if err := c.loadScripts(ctx, loader); err != nil {
+			c.logger.Error("redislock: reload scripts failed", "error", err)
+		}
+	}()
+}
+
+func (c *Client) loadScripts(ctx context.Context, loader ScriptLoader) error {
+	for _, name := range scriptNames() {
+		if err := loader.ScriptLoad(ctx, scripts[name]); err != nil {
+			return fmt.Errorf("loader.ScriptLoad %s error: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// scriptNames returns the names of scripts in order.
+func scriptNames() []string {
+	names := make([]string, 0, len(scripts))
+	for name := range scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,119 @@
+package redislock
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// serverExpiry is the expiry of a lock observed in redis, see WithServerTime.
+type serverExpiry struct {
+	ttl time.Duration // PTTL of the lock.
+	now time.Time     // TIME of redis when ttl was observed.
+}
+
+// deadline returns the expiry of the lock in the time of redis.
+func (e serverExpiry) deadline() time.Time {
+	return e.now.Add(e.ttl)
+}
+
+// parseServerExpiry parses the reply of a server time script, which ends with PTTL and TIME.
+func parseServerExpiry(values []interface{}) (serverExpiry, error) {
+	if len(values) < 3 {
+		return serverExpiry{}, fmt.Errorf("unexpected reply %v of server time script", values)
+	}
+	values = values[len(values)-3:]
+
+	var parsed [3]int64
+	for i, value := range values {
+		var err error
+		if parsed[i], err = toInt64(value); err != nil {
+			return serverExpiry{}, fmt.Errorf("unexpected reply %v of server time script: %w", values, err)
+		}
+	}
+	return serverExpiry{
+		ttl: time.Duration(parsed[0]) * time.Millisecond,
+		now: time.Unix(parsed[1], parsed[2]*int64(time.Microsecond)),
+	}, nil
+}
+
+// toInt64 converts an integer reply or a bulk string reply of an integer, such as the replies of TIME.
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("%T is not an integer", value)
+	}
+}
+
+// lockServerTime acquires the lock like lock and returns its expiry observed in redis,
+// the lock is released if too few replicas acknowledge it, see WithWait.
+func (c *Client) lockServerTime(ctx context.Context, key, value string, expiration time.Duration) (ok bool, fencingToken int64, expiry serverExpiry, err error) {
+	keys := []string{key}
+	if c.fencing {
+		keys = append(keys, FencingKey(key))
+	}
+
+	cmd, waitErr := c.evalWait(ctx, true, luaLockServerTime, keys, value, expiration.Milliseconds())
+	values, err := cmd.Slice()
+	if err == redis.Nil {
+		return false, 0, serverExpiry{}, nil
+	} else if err != nil {
+		return false, 0, serverExpiry{}, err
+	}
+
+	if waitErr != nil {
+		c.rollback(luaUnlock, []string{key}, value)
+		return false, 0, serverExpiry{}, waitErr
+	}
+
+	if expiry, err = parseServerExpiry(values); err != nil {
+		return false, 0, serverExpiry{}, err
+	}
+	if c.fencing {
+		if fencingToken, err = toInt64(values[0]); err != nil {
+			return false, 0, serverExpiry{}, err
+		}
+	}
+	return true, fencingToken, expiry, nil
+}
+
+// pexpireServerTime sets the expiration of the lock like pexpire,
+// the deadline is taken from the PTTL observed in redis.
+func (m *Mutex) pexpireServerTime(ctx context.Context, expiration time.Duration) error {
+	now := m.client.clock.Now()
+	cmd, waitErr := m.client.evalWait(ctx, m.client.waitRefresh, luaRefreshServerTime, []string{m.key}, m.value, expiration.Milliseconds())
+	values, err := cmd.Slice()
+	if err == redis.Nil {
+		return ErrMutexNotHeld
+	} else if err != nil {
+		return err
+	}
+
+	if waitErr != nil {
+		return waitErr
+	}
+
+	expiry, err := parseServerExpiry(values)
+	if err != nil {
+		return err
+	}
+	m.setServerExpiry(now, expiry)
+	return nil
+}
+
+// setServerExpiry records expiry observed by a request sent at now,
+// the local deadline is now plus the observed PTTL, so it does not depend on the clock of redis.
+func (m *Mutex) setServerExpiry(now time.Time, expiry serverExpiry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ttl = expiry.ttl
+	m.deadline = now.Add(expiry.ttl)
+	m.serverDeadline = expiry.deadline()
+}
@@ -0,0 +1,187 @@
+package redislock
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultResultTTL is the default time a single flight result is kept.
+	DefaultResultTTL = time.Minute
+	// DefaultErrorTTL is the default time a single flight error is kept.
+	DefaultErrorTTL = time.Second
+	// DefaultPollInterval is the default interval waiters check the result,
+	// in case notifications are not supported or missed.
+	DefaultPollInterval = time.Second
+)
+
+// result prefixes of the stored results.
+const (
+	resultValue = 'v'
+	resultError = 'e'
+)
+
+// SingleFlight coalesces the calls of many processes for the same key:
+// the first caller acquires the lock of key and calls fn,
+// the others wait for the result it publishes to redis.
+type SingleFlight struct {
+	client       *Client
+	resultTTL    time.Duration // time a result is kept, callers within it get the result without calling fn.
+	errorTTL     time.Duration // time an error is kept.
+	pollInterval time.Duration // interval waiters check the result.
+}
+
+// NewSingleFlight creates a new SingleFlight.
+func (c *Client) NewSingleFlight(options ...SingleFlightOption) *SingleFlight {
+	s := &SingleFlight{
+		client:       c,
+		resultTTL:    DefaultResultTTL,
+		errorTTL:     DefaultErrorTTL,
+		pollInterval: DefaultPollInterval,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+type SingleFlightOption func(singleFlight *SingleFlight)
+
+// WithResultTTL sets the time a result is kept.
+func WithResultTTL(resultTTL time.Duration) SingleFlightOption {
+	return func(singleFlight *SingleFlight) {
+		singleFlight.resultTTL = resultTTL
+	}
+}
+
+// WithErrorTTL sets the time an error is kept.
+func WithErrorTTL(errorTTL time.Duration) SingleFlightOption {
+	return func(singleFlight *SingleFlight) {
+		singleFlight.errorTTL = errorTTL
+	}
+}
+
+// WithPollInterval sets the interval waiters check the result.
+func WithPollInterval(pollInterval time.Duration) SingleFlightOption {
+	return func(singleFlight *SingleFlight) {
+		singleFlight.pollInterval = pollInterval
+	}
+}
+
+// Do returns the result of fn for key, fn is called by one caller at a time across processes,
+// the others wait for its result. If fn fails, the caller calling it gets the error of fn
+// and the waiters get an error wrapping ErrSingleFlightFailed with the message of the error.
+// If the caller calling fn crashes, a waiter calls fn once the lock expires.
+// The ctx of fn is done when the lock is lost, and the result of a caller which lost the lock
+// is not published, it gets an error wrapping ErrMutexNotHeld instead.
+func (s *SingleFlight) Do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	var notify <-chan struct{}
+	subscribed := false
+	for {
+		value, found, err := s.result(ctx, key)
+		if err != nil || found {
+			return value, err
+		}
+
+		mutex, err := s.client.acquire(ctx, s.lockKey(key), nil)
+		if err == nil {
+			return s.call(ctx, key, mutex, fn)
+		} else if !IsMutexLockFailed(err) {
+			return nil, err
+		}
+
+		if !subscribed {
+			// subscribe only before waiting, so callers finding the result hold no connection.
+			var closeSubscription func()
+			notify, closeSubscription, err = s.client.backend.Subscribe(ctx, s.channel(key))
+			if err != nil {
+				return nil, fmt.Errorf("s.client.subscribe error: %w", err)
+			}
+			defer closeSubscription()
+			subscribed = true
+
+			// the result may be published before the subscription, check it again at once.
+			continue
+		}
+
+		if err = s.client.waitNotified(ctx, notify, s.pollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// call calls fn holding mutex and publishes its result.
+func (s *SingleFlight) call(ctx context.Context, key string, mutex *Mutex, fn func(ctx context.Context) ([]byte, error)) (_ []byte, err error) {
+	defer func() {
+		releaseCtx, cancel := releaseContext(ctx)
+		defer cancel()
+		if unlockErr := mutex.Unlock(releaseCtx); unlockErr != nil && err == nil {
+			err = fmt.Errorf("mutex.Unlock error: %w", unlockErr)
+		}
+	}()
+
+	// the result may be published between checking it and acquiring the lock.
+	value, found, err := s.result(ctx, key)
+	if err != nil || found {
+		return value, err
+	}
+
+	lockCtx, cancel := mutex.lockContext(ctx)
+	defer cancel()
+	value, fnErr := fn(lockCtx)
+
+	stored, ttl := append([]byte{resultValue}, value...), s.resultTTL
+	if fnErr != nil {
+		stored, ttl = append([]byte{resultError}, fnErr.Error()...), s.errorTTL
+	}
+
+	published, err := s.client.eval(ctx, luaPublishHeldResult, []string{mutex.key, s.resultKey(key)},
+		mutex.value, stored, ttl.Milliseconds(), s.channel(key)).Int()
+	if err == nil && published == 0 {
+		// another caller may be calling fn, its result is published instead.
+		err = ErrMutexNotHeld
+	}
+	if fnErr != nil {
+		return nil, fnErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("publish result error: %w", err)
+	}
+	return value, nil
+}
+
+// result returns the published result of key, found is false if there is no result.
+func (s *SingleFlight) result(ctx context.Context, key string) (value []byte, found bool, err error) {
+	stored, found, err := s.client.backend.Get(ctx, s.resultKey(key))
+	if err != nil || !found {
+		return nil, false, err
+	}
+
+	if len(stored) == 0 {
+		return nil, false, nil
+	}
+
+	if stored[0] == resultError {
+		return nil, true, fmt.Errorf("%w: %s", ErrSingleFlightFailed, stored[1:])
+	}
+	return []byte(stored[1:]), true, nil
+}
+
+// lockKey returns the key of the lock of the caller calling fn.
+func (s *SingleFlight) lockKey(key string) string {
+	return sameSlotKey(key, ":lock")
+}
+
+// resultKey returns the key of the published result, which is in the slot of the lock key,
+// so the result is published in one script with the check of the lock.
+func (s *SingleFlight) resultKey(key string) string {
+	return sameSlotKey(key, ":result")
+}
+
+// channel returns the channel notified when the result is published.
+func (s *SingleFlight) channel(key string) string {
+	return key + ":published"
+}
@@ -0,0 +1,85 @@
+package redislock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestSingleFlight_Do(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	keyOne := "testOne"
+	keyTwo := "testTwo"
+	defer teardown(t, rdb, []string{"{" + keyOne + "}:result", "{" + keyOne + "}:lock", "{" + keyTwo + "}:result", "{" + keyTwo + "}:lock"})
+
+	errFn := errors.New("fn failed")
+
+	// test cases
+	cases := []struct {
+		Name  string
+		Key   string
+		Value []byte
+		Err   error
+	}{
+		{"DoValue", keyOne, []byte("value"), nil},
+		{"DoError", keyTwo, nil, errFn},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			var calls int32
+			fn := func(ctx context.Context) ([]byte, error) {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(100 * time.Millisecond)
+				return c.Value, c.Err
+			}
+
+			// callers of different processes
+			var wg sync.WaitGroup
+			values := make([][]byte, 5)
+			errs := make([]error, 5)
+			for i := 0; i < 5; i++ {
+				client, err := NewClient(rdb)
+				if err != nil {
+					t.Fatalf("NewClient error:[%v]", err)
+				}
+				singleFlight := client.NewSingleFlight(WithPollInterval(time.Second))
+
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					values[i], errs[i] = singleFlight.Do(context.Background(), c.Key, fn)
+				}(i)
+			}
+			wg.Wait()
+
+			if calls != 1 {
+				t.Errorf("calls is not equal,expected %v, got %v", 1, calls)
+			}
+
+			for i := range values {
+				if string(values[i]) != string(c.Value) {
+					t.Errorf("value is not equal,expected %s, got %s", c.Value, values[i])
+				}
+
+				if c.Err == nil && errs[i] != nil {
+					t.Errorf("Do error:[%v]", errs[i])
+				}
+
+				if c.Err != nil && !errors.Is(errs[i], c.Err) && !IsSingleFlightFailed(errs[i]) {
+					t.Errorf("err is not equal,expected %v or %v, got %v", c.Err, ErrSingleFlightFailed, errs[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package redislock
+
+import (
+	"context"
+	"errors"
+)
+
+// operation names used by redislock when starting spans.
+const (
+	OperationTryLock         = "redislock.TryLock"
+	OperationUnlock          = "redislock.Unlock"
+	OperationRefresh         = "redislock.Refresh"
+	OperationExtend          = "redislock.Extend"
+	OperationTransfer        = "redislock.Transfer"
+	OperationWatchDogRefresh = "redislock.WatchDogRefresh"
+)
+
+// attribute keys set by redislock on spans.
+const (
+	AttributeKey        = "redislock.key"
+	AttributeRetryCount = "redislock.retry_count"
+	AttributeOutcome    = "redislock.outcome"
+)
+
+// outcomes of an operation, set as AttributeOutcome.
+const (
+	OutcomeAcquired  = "acquired"
+	OutcomeFailed    = "failed"
+	OutcomeCancelled = "cancelled"
+	OutcomeError     = "error"
+)
+
+// Tracer is the interface used by redislock to trace lock operations,
+// redislockotel provides an implementation based on OpenTelemetry.
+type Tracer interface {
+	// Start starts a span for operation on key, the returned context carries the span.
+	Start(ctx context.Context, operation, key string) (context.Context, Span)
+}
+
+// Span is a traced lock operation.
+type Span interface {
+	// SetAttribute sets an attribute on the span.
+	SetAttribute(key string, value interface{})
+	// End ends the span, err is the result of the operation.
+	End(err error)
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _, _ string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+
+func (noopSpan) End(error) {}
+
+// tryLockOutcome returns the outcome of tryLock by its error.
+func tryLockOutcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeAcquired
+	case IsMutexLockFailed(err):
+		return OutcomeFailed
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return OutcomeCancelled
+	default:
+		return OutcomeError
+	}
+}
+
+// trace runs fn in a span of operation on key.
+func (c *Client) trace(ctx context.Context, operation, key string, fn func(ctx context.Context) error) error {
+	ctx, span := c.tracer.Start(ctx, operation, key)
+	err := fn(ctx)
+	span.End(err)
+	return err
+}
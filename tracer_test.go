@@ -0,0 +1,115 @@
+package redislock
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type recordSpan struct {
+	operation  string
+	key        string
+	attributes map[string]interface{}
+	err        error
+}
+
+type recordTracer struct {
+	mu    sync.Mutex
+	spans []*recordSpan
+}
+
+func (r *recordTracer) Start(ctx context.Context, operation, key string) (context.Context, Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	span := &recordSpan{operation: operation, key: key, attributes: make(map[string]interface{})}
+	r.spans = append(r.spans, span)
+	return ctx, span
+}
+
+func (r *recordSpan) SetAttribute(key string, value interface{}) {
+	r.attributes[key] = value
+}
+
+func (r *recordSpan) End(err error) {
+	r.err = err
+}
+
+func TestClient_WithTracer(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	tracer := &recordTracer{}
+	// init redislock client
+	client, err := NewClient(rdb, WithTracer(tracer))
+	if err != nil {
+		t.Fatalf("NewClient error:[%v]", err)
+	}
+	key := "testOne"
+	defer teardown(t, rdb, []string{key})
+
+	ctx := context.Background()
+
+	mutex, err := client.TryLock(ctx, key, 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+
+	_, err = client.TryLockWithRetryStrategy(ctx, key, 10*time.Second, NewAverageRetry(2, 10*time.Millisecond))
+	if !IsMutexLockFailed(err) {
+		t.Fatalf("TryLockWithRetryStrategy error:[%v]", err)
+	}
+
+	if err = mutex.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh error:[%v]", err)
+	}
+
+	if err = mutex.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock error:[%v]", err)
+	}
+
+	// test cases
+	cases := []struct {
+		Name       string
+		Operation  string
+		Attributes map[string]interface{}
+		Err        error
+	}{
+		{"TryLockAcquired", OperationTryLock, map[string]interface{}{AttributeRetryCount: 0, AttributeOutcome: OutcomeAcquired}, nil},
+		{"TryLockFailed", OperationTryLock, map[string]interface{}{AttributeRetryCount: 2, AttributeOutcome: OutcomeFailed}, ErrMutexLockFailed},
+		{"Refresh", OperationRefresh, map[string]interface{}{}, nil},
+		{"Unlock", OperationUnlock, map[string]interface{}{}, nil},
+	}
+
+	if len(tracer.spans) != len(cases) {
+		t.Fatalf("spans is not equal,expected %v, got %v", len(cases), len(tracer.spans))
+	}
+
+	for i, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			span := tracer.spans[i]
+			if span.operation != c.Operation {
+				t.Errorf("operation is not equal,expected %v, got %v", c.Operation, span.operation)
+			}
+
+			if span.key != key {
+				t.Errorf("key is not equal,expected %v, got %v", key, span.key)
+			}
+
+			for k, v := range c.Attributes {
+				if span.attributes[k] != v {
+					t.Errorf("attribute %v is not equal,expected %v, got %v", k, v, span.attributes[k])
+				}
+			}
+
+			if span.err != c.Err {
+				t.Errorf("err is not equal,expected %v, got %v", c.Err, span.err)
+			}
+		})
+	}
+}
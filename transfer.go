@@ -0,0 +1,65 @@
+package redislock
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// NewToken returns a new lock value unique to this client,
+// the successor of a lock creates it and passes it to the holder, see Mutex.TransferTo.
+func (c *Client) NewToken() (string, error) {
+	value, err := c.getValue()
+	if err != nil {
+		return "", fmt.Errorf("c.getValue error: %w", err)
+	}
+	return value, nil
+}
+
+// TransferTo atomically replaces the value of the lock with token if the lock is still held,
+// keeping the remaining expiration, so no one else can acquire the lock in between.
+// The watch dog of the mutex is stopped and the mutex no longer holds the lock,
+// if the transfer fails with an error of redis, the watch dog is restarted.
+// the returned handle is resumed by the successor, see Client.Resume.
+func (m *Mutex) TransferTo(ctx context.Context, token string) (*Handle, error) {
+	if m == nil {
+		return nil, ErrMutexNotInitialized
+	}
+
+	// stop the watch dog first, so a refresh in flight does not take the transfer for a lost lock.
+	if m.watchDog != nil {
+		m.stopWatchDog()
+	}
+
+	var ttl int64
+	err := m.client.trace(ctx, OperationTransfer, m.key, func(ctx context.Context) error {
+		var err error
+		ttl, err = m.client.eval(ctx, luaTransfer, []string{m.key}, m.value, token).Int64()
+		return err
+	})
+	if err != nil {
+		// the lock may still be held, keep it.
+		if m.watchDog != nil {
+			m.restartWatchDog()
+		}
+		return nil, err
+	}
+
+	// -2 means the lock is not held.
+	if ttl == -2 {
+		m.markLost()
+		return nil, ErrMutexNotHeld
+	}
+
+	handle := &Handle{
+		Key:          m.key,
+		Token:        []byte(token),
+		FencingToken: m.fencingToken,
+		Expiration:   m.getExpiration(),
+		AcquiredAt:   m.acquiredAt,
+	}
+	if ttl > 0 {
+		handle.ExpiresAt = m.client.clock.Now().Add(time.Duration(ttl) * time.Millisecond)
+	}
+	return handle, nil
+}
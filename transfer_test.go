@@ -0,0 +1,85 @@
+package redislock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMutex_TransferTo(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	// init redislock clients of the holder and the successor
+	holder, err := NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("holder NewDefaultClient error:[%v]", err)
+	}
+
+	successor, err := NewClient(rdb)
+	if err != nil {
+		t.Fatalf("successor NewClient error:[%v]", err)
+	}
+	key := "testOne"
+	defer teardown(t, rdb, []string{key})
+
+	ctx := context.Background()
+
+	mutex, err := holder.TryLock(ctx, key, 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+
+	token, err := successor.NewToken()
+	if err != nil {
+		t.Fatalf("NewToken error:[%v]", err)
+	}
+
+	handle, err := mutex.TransferTo(ctx, token)
+	if err != nil {
+		t.Fatalf("TransferTo error:[%v]", err)
+	}
+
+	value, err := rdb.Get(ctx, key).Result()
+	if err != nil {
+		t.Fatalf("Get error:[%v]", err)
+	}
+
+	if value != token {
+		t.Errorf("value is not equal,expected %v, got %v", token, value)
+	}
+
+	if handle.ExpiresAt.IsZero() || handle.ExpiresAt.After(time.Now().Add(10*time.Second)) {
+		t.Errorf("expiresAt is not in the remaining expiration, got %v", handle.ExpiresAt)
+	}
+
+	t.Run("TransferToNotHeld", func(t *testing.T) {
+		if _, err := mutex.TransferTo(ctx, token); !IsMutexNotHeld(err) {
+			t.Errorf("TransferTo error is not equal,expected %v, got %v", ErrMutexNotHeld, err)
+		}
+
+		if err := mutex.Unlock(ctx); !IsMutexNotHeld(err) {
+			t.Errorf("Unlock error is not equal,expected %v, got %v", ErrMutexNotHeld, err)
+		}
+	})
+
+	t.Run("ResumeTransferred", func(t *testing.T) {
+		resumed, err := successor.Resume(ctx, handle)
+		if err != nil {
+			t.Fatalf("Resume error:[%v]", err)
+		}
+
+		if resumed.Token() != token {
+			t.Errorf("token is not equal,expected %v, got %v", token, resumed.Token())
+		}
+
+		if err = resumed.Unlock(ctx); err != nil {
+			t.Errorf("Unlock error:[%v]", err)
+		}
+	})
+}
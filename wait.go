@@ -0,0 +1,78 @@
+package redislock
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// evalWait runs script, then waits for the replicas to acknowledge it if wait is true and the client uses WithWait.
+// waitErr is ErrNotEnoughReplicas if too few replicas acknowledged script in time, or the error of WAIT,
+// the reply of script is valid then, so the caller can roll script back.
+func (c *Client) evalWait(ctx context.Context, wait bool, script *Script, keys []string, args ...interface{}) (cmd *redis.Cmd, waitErr error) {
+	if !wait || c.waitReplicas <= 0 {
+		return c.eval(ctx, script, keys, args...), nil
+	}
+
+	waiter, ok := c.backend.(ReplicaWaiter)
+	if !ok {
+		return redis.NewCmdResult(nil, ErrWaitUnsupported), nil
+	}
+
+	reply, acked, err := waiter.EvalWait(ctx, script, keys, args, c.waitReplicas, c.waitTimeout)
+	switch {
+	case reply == nil && err == nil:
+		return redis.NewCmdResult(nil, redis.Nil), nil
+	case reply == nil:
+		return redis.NewCmdResult(nil, err), nil
+	case err != nil:
+		return redis.NewCmdResult(reply, nil), fmt.Errorf("WAIT error: %w", err)
+	case acked < int64(c.waitReplicas):
+		c.logger.Warn("redislock: not enough replicas acknowledged", "keys", keys, "replicas", acked, "expected", c.waitReplicas)
+		return redis.NewCmdResult(reply, nil), ErrNotEnoughReplicas
+	default:
+		return redis.NewCmdResult(reply, nil), nil
+	}
+}
+
+// lockWait acquires the lock by a script and waits for the replicas to acknowledge it,
+// the lock is released if they do not.
+func (c *Client) lockWait(ctx context.Context, key, value string, expiration time.Duration) (ok bool, fencingToken int64, err error) {
+	script, keys := luaSetNX, []string{key}
+	if c.fencing {
+		script, keys = luaLockWithFencing, []string{key, FencingKey(key)}
+	}
+
+	cmd, waitErr := c.evalWait(ctx, true, script, keys, value, expiration.Milliseconds())
+	if err = cmd.Err(); err == redis.Nil {
+		return false, 0, nil
+	} else if err != nil {
+		return false, 0, err
+	}
+
+	if c.fencing {
+		if fencingToken, err = cmd.Int64(); err != nil || fencingToken == 0 {
+			return false, 0, err
+		}
+	}
+
+	if waitErr != nil {
+		c.rollback(luaUnlock, []string{key}, value)
+		return false, 0, waitErr
+	}
+	return true, fencingToken, nil
+}
+
+// rollback undoes the lock of keys taken as value by running script with value and args,
+// after too few replicas acknowledged it. It is run even if the caller has given up,
+// bounded by ReleaseTimeout, so it does not block on a hung connection.
+func (c *Client) rollback(script *Script, keys []string, value string, args ...interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), ReleaseTimeout)
+	defer cancel()
+
+	if err := c.eval(ctx, script, keys, append([]interface{}{value}, args...)...).Err(); err != nil {
+		c.logger.Error("redislock: roll back lock failed", "key", keys[0], "token", logToken(value), "error", err)
+	}
+}
@@ -14,6 +14,7 @@ type WatchDog struct {
 	expiration time.Duration      // lock expiration.
 	cancelFunc context.CancelFunc // cancel function.
 	isStart    uint32             // watch dog is start or not start.
+	done       chan struct{}      // closed when the goroutine of the watch dog returns.
 }
 
 // NewWatchDog creates a new WatchDog.
@@ -0,0 +1,251 @@
+package redislock
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReleaseTimeout is the timeout of releasing the lock by WithLock after the context of the caller is done.
+const ReleaseTimeout = 5 * time.Second
+
+// LockOption configures how a lock is acquired by WithLock.
+type LockOption func(option *lockOption)
+
+type lockOption struct {
+	expiration    time.Duration // -1 means no expiration, so start watch dog.
+	retryStrategy RetryStrategy
+	watchDog      *WatchDog
+}
+
+// WithExpiration sets the expiration of the lock,
+// -1 means no expiration and the lock is kept by a default watch dog.
+func WithExpiration(expiration time.Duration) LockOption {
+	return func(option *lockOption) {
+		option.expiration = expiration
+	}
+}
+
+// WithRetryStrategy sets the retry strategy used to acquire the lock.
+func WithRetryStrategy(retryStrategy RetryStrategy) LockOption {
+	return func(option *lockOption) {
+		option.retryStrategy = retryStrategy
+	}
+}
+
+// WithWatchDog sets the watch dog keeping the lock,
+// if you set WithExpiration and WithWatchDog at the same time,
+// WithExpiration will be ignored.
+func WithWatchDog(watchDog *WatchDog) LockOption {
+	return func(option *lockOption) {
+		option.watchDog = watchDog
+	}
+}
+
+// acquire acquires the lock of key configured by options,
+// default is no retry and a default watch dog.
+func (c *Client) acquire(ctx context.Context, key string, options []LockOption) (*Mutex, error) {
+	option := &lockOption{expiration: -1, retryStrategy: NewNoRetry()}
+
+	for _, o := range options {
+		o(option)
+	}
+
+	if option.watchDog != nil {
+		return c.TryLockWithRetryAndWatchDog(ctx, key, option.retryStrategy, option.watchDog)
+	}
+	return c.TryLockWithRetryStrategy(ctx, key, option.expiration, option.retryStrategy)
+}
+
+// releaseContext returns the context to release a lock with after the caller is done with ctx,
+// the lock is released even if the caller has given up, but does not block on a hung connection.
+func releaseContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx.Err() == nil {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(context.Background(), ReleaseTimeout)
+}
+
+// WithLock acquires the lock of key, runs fn and releases the lock, even if fn panics.
+// The context passed to fn is cancelled when the lock is lost,
+// or when the lock expires if it is not kept by a watch dog.
+// The returned error is a *WithLockError telling which step failed.
+func (c *Client) WithLock(ctx context.Context, key string, fn func(ctx context.Context) error, options ...LockOption) (err error) {
+	mutex, err := c.acquire(ctx, key, options)
+	if err != nil {
+		return &WithLockError{AcquireErr: err}
+	}
+
+	fnCtx, cancel := mutex.lockContext(ctx)
+
+	var funcErr error
+	completed := false
+	defer func() {
+		cancel()
+
+		releaseCtx, cancelRelease := releaseContext(ctx)
+		defer cancelRelease()
+		releaseErr := mutex.Unlock(releaseCtx)
+
+		// fn panics, the panic keeps propagating.
+		if !completed {
+			return
+		}
+
+		if funcErr != nil || releaseErr != nil {
+			err = &WithLockError{FuncErr: funcErr, ReleaseErr: releaseErr}
+		}
+	}()
+
+	funcErr = fn(fnCtx)
+	completed = true
+
+	return nil
+}
+
+// lockContext returns a context derived from ctx that is cancelled when the lock is lost,
+// or when the lock expires if the mutex has no watch dog, the deadline follows Extend.
+func (m *Mutex) lockContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.watchDog == nil {
+		return newDeadlineContext(ctx, m)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-m.lost:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// deadlineContext is a context that is done when the lock of mutex expires,
+// its deadline is the deadline of mutex, which Extend moves.
+type deadlineContext struct {
+	context.Context
+	mutex *Mutex
+	done  chan struct{}
+	once  sync.Once
+	mu    sync.Mutex
+	err   error
+}
+
+func newDeadlineContext(parent context.Context, mutex *Mutex) (context.Context, context.CancelFunc) {
+	ctx := &deadlineContext{Context: parent, mutex: mutex, done: make(chan struct{})}
+	clock := mutex.client.clock
+
+	go func() {
+		for {
+			extended := mutex.extendedChan()
+			timer, stop := clock.NewTimer(mutex.Deadline().Sub(clock.Now()))
+			select {
+			case <-parent.Done():
+				stop()
+				ctx.cancel(parent.Err())
+				return
+			case <-mutex.lost:
+				stop()
+				ctx.cancel(context.Canceled)
+				return
+			case <-ctx.done:
+				stop()
+				return
+			case <-extended:
+				stop()
+			case <-timer:
+				// a refresh may move the deadline without waking the context.
+				if clock.Now().Before(mutex.Deadline()) {
+					continue
+				}
+				ctx.cancel(context.DeadlineExceeded)
+				return
+			}
+		}
+	}()
+
+	return ctx, func() { ctx.cancel(context.Canceled) }
+}
+
+func (c *deadlineContext) Deadline() (time.Time, bool) {
+	deadline := c.mutex.Deadline()
+	if parent, ok := c.Context.Deadline(); ok && parent.Before(deadline) {
+		return parent, true
+	}
+	return deadline, true
+}
+
+func (c *deadlineContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *deadlineContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *deadlineContext) cancel(err error) {
+	c.once.Do(func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		close(c.done)
+	})
+}
+
+// WithLockError is the error returned by WithLock, it tells which step failed.
+type WithLockError struct {
+	AcquireErr error // error acquiring the lock, fn is not run.
+	FuncErr    error // error returned by fn.
+	ReleaseErr error // error releasing the lock, ErrMutexNotHeld if the lock was lost.
+}
+
+func (e *WithLockError) errors() []error {
+	var errs []error
+	for _, err := range []error{e.AcquireErr, e.FuncErr, e.ReleaseErr} {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Error returns the messages of all failed steps.
+func (e *WithLockError) Error() string {
+	var messages []string
+	if e.AcquireErr != nil {
+		messages = append(messages, "acquire lock error: "+e.AcquireErr.Error())
+	}
+	if e.FuncErr != nil {
+		messages = append(messages, "fn error: "+e.FuncErr.Error())
+	}
+	if e.ReleaseErr != nil {
+		messages = append(messages, "release lock error: "+e.ReleaseErr.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Is reports whether any failed step matches target.
+func (e *WithLockError) Is(target error) bool {
+	for _, err := range e.errors() {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first failed step that matches target.
+func (e *WithLockError) As(target interface{}) bool {
+	for _, err := range e.errors() {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
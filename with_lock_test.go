@@ -0,0 +1,171 @@
+package redislock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestClient_WithLock(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	// init redislock client
+	client, err := NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+	keyOne := "testOne"
+	keyTwo := "testTwo"
+	defer teardown(t, rdb, []string{keyOne, keyTwo})
+
+	ctx := context.Background()
+
+	held, err := client.TryLock(ctx, keyTwo, 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock error:[%v]", err)
+	}
+	defer held.Unlock(ctx)
+
+	errFunc := errors.New("fn failed")
+
+	// test cases
+	cases := []struct {
+		Name       string
+		Key        string
+		Fn         func(ctx context.Context) error
+		Options    []LockOption
+		AcquireErr error
+		FuncErr    error
+		ReleaseErr error
+	}{
+		{
+			Name: "WithLockSucceeded",
+			Key:  keyOne,
+			Fn: func(ctx context.Context) error {
+				return nil
+			},
+		},
+		{
+			Name: "WithLockWithExpiration",
+			Key:  keyOne,
+			Fn: func(ctx context.Context) error {
+				if _, ok := ctx.Deadline(); !ok {
+					return errors.New("ctx has no deadline")
+				}
+				return nil
+			},
+			Options: []LockOption{WithExpiration(10 * time.Second)},
+		},
+		{
+			Name: "WithLockAcquireFailed",
+			Key:  keyTwo,
+			Fn: func(ctx context.Context) error {
+				return nil
+			},
+			Options:    []LockOption{WithRetryStrategy(NewAverageRetry(1, 10*time.Millisecond))},
+			AcquireErr: ErrMutexLockFailed,
+		},
+		{
+			Name: "WithLockFuncFailed",
+			Key:  keyOne,
+			Fn: func(ctx context.Context) error {
+				return errFunc
+			},
+			FuncErr: errFunc,
+		},
+		{
+			Name: "WithLockReleaseFailed",
+			Key:  keyOne,
+			Fn: func(ctx context.Context) error {
+				return rdb.Del(ctx, keyOne).Err()
+			},
+			ReleaseErr: ErrMutexNotHeld,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			err := client.WithLock(ctx, c.Key, c.Fn, c.Options...)
+			if c.AcquireErr == nil && c.FuncErr == nil && c.ReleaseErr == nil {
+				if err != nil {
+					t.Fatalf("WithLock error:[%v]", err)
+				}
+				return
+			}
+
+			var withLockErr *WithLockError
+			if !errors.As(err, &withLockErr) {
+				t.Fatalf("WithLock error is not *WithLockError:[%v]", err)
+			}
+
+			if !errors.Is(withLockErr.AcquireErr, c.AcquireErr) {
+				t.Errorf("acquire error is not equal,expected %v, got %v", c.AcquireErr, withLockErr.AcquireErr)
+			}
+
+			if !errors.Is(withLockErr.FuncErr, c.FuncErr) {
+				t.Errorf("fn error is not equal,expected %v, got %v", c.FuncErr, withLockErr.FuncErr)
+			}
+
+			if !errors.Is(withLockErr.ReleaseErr, c.ReleaseErr) {
+				t.Errorf("release error is not equal,expected %v, got %v", c.ReleaseErr, withLockErr.ReleaseErr)
+			}
+		})
+	}
+
+	t.Run("WithLockReleasedOnPanic", func(t *testing.T) {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("WithLock does not propagate the panic")
+				}
+			}()
+			_ = client.WithLock(ctx, keyOne, func(ctx context.Context) error {
+				panic("fn panicked")
+			})
+		}()
+
+		exists, err := rdb.Exists(ctx, keyOne).Result()
+		if err != nil {
+			t.Fatalf("Exists error:[%v]", err)
+		}
+
+		if exists != 0 {
+			t.Errorf("lock is not released after panic")
+		}
+	})
+}
+
+func TestMutex_lockContext(t *testing.T) {
+	// init redis client
+	rdb := redis.NewClient(&redis.Options{
+		Addr: ":6379",
+	})
+	// close redis client
+	defer rdb.Close()
+	// init redislock client
+	client, err := NewDefaultClient(rdb)
+	if err != nil {
+		t.Fatalf("NewDefaultClient error:[%v]", err)
+	}
+
+	mutex := newMutex(client, "testOne", "value", 10*time.Second, NewNoRetry())
+	mutex.setWatchDog(NewDefaultWatchDog())
+
+	ctx, cancel := mutex.lockContext(context.Background())
+	defer cancel()
+
+	mutex.markLost()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Errorf("lock context is not cancelled after lock lost")
+	}
+}